@@ -0,0 +1,280 @@
+package astprinter
+
+import (
+	"fmt"
+	"learning/glox/expr"
+	"learning/glox/scanner"
+	"learning/glox/token"
+	"learning/glox/value"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Reader read s-expression, which is printed by expr Visit(), back to expr ast
+type Reader struct {
+	runes   []rune // s-expression rune slice
+	current int    // reader current location
+}
+
+// Read parse s-expression source, eg: (* (- 123) (group 45.67)), to expr,
+// s-expression has no positions, so tokens are on line 0, and parens, brackets
+// and braces which are not printed are made up like the parser would store them
+func Read(source string) (expr.Expr, error) {
+	r := Reader{
+		runes: []rune(source),
+	}
+	res, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+	r.skipSpace()
+	if !r.isAtEnd() {
+		return nil, fmt.Errorf(
+			"[column %d] unexpected '%c' after expression",
+			r.current, r.runes[r.current])
+	}
+	return res, nil
+}
+
+func (r *Reader) read() (expr.Expr, error) {
+	r.skipSpace()
+	if r.isAtEnd() {
+		return nil, fmt.Errorf("unexpected end of s-expression")
+	}
+	switch r.runes[r.current] {
+	case '(':
+		r.current++
+		return r.readList()
+	case ')':
+		return nil, fmt.Errorf("[column %d] unexpected ')'", r.current)
+	case '"':
+		return r.readString()
+	}
+	return r.readAtom(r.readWord())
+}
+
+// readList read (name operand...), name is operator lexeme or group
+func (r *Reader) readList() (expr.Expr, error) {
+	r.skipSpace()
+	name := r.readWord()
+	if name == "" {
+		return nil, fmt.Errorf("[column %d] expect operator after '('", r.current)
+	}
+	operands := []expr.Expr{}
+	for {
+		r.skipSpace()
+		if r.isAtEnd() {
+			return nil, fmt.Errorf("expect ')' after operands of '%s'", name)
+		}
+		if r.runes[r.current] == ')' {
+			r.current++
+			break
+		}
+		operand, err := r.read()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+
+	if name == "group" && len(operands) == 1 {
+		return &expr.Grouping{
+			Paren:      token.Token{Type: token.LEFTPAREN, Lexeme: "("},
+			Expression: operands[0],
+		}, nil
+	}
+	if name == "?:" && len(operands) == 3 {
+		return &expr.Conditional{
+			Condition:  operands[0],
+			ThenBranch: operands[1],
+			ElseBranch: operands[2],
+		}, nil
+	}
+	bracket := token.Token{Type: token.LEFTBRACKET, Lexeme: "["}
+	if name == "list" {
+		return &expr.List{
+			Bracket:  bracket,
+			Elements: operands,
+		}, nil
+	}
+	if name == "map" && len(operands)%2 == 0 {
+		res := &expr.Map{
+			Brace:  token.Token{Type: token.LEFTBRACE, Lexeme: "{"},
+			Keys:   []expr.Expr{},
+			Values: []expr.Expr{},
+		}
+		for i := 0; i < len(operands); i += 2 {
+			res.Keys = append(res.Keys, operands[i])
+			res.Values = append(res.Values, operands[i+1])
+		}
+		return res, nil
+	}
+	if name == "call" && len(operands) >= 1 {
+		return &expr.Call{
+			Callee:    operands[0],
+			Paren:     token.Token{Type: token.RIGHTPAREN, Lexeme: ")"},
+			Arguments: operands[1:],
+		}, nil
+	}
+	if name == "index" && len(operands) == 2 {
+		return &expr.Index{
+			Object:  operands[0],
+			Bracket: bracket,
+			Index:   operands[1],
+		}, nil
+	}
+	if name == "slice" && len(operands) == 3 {
+		return &expr.Slice{
+			Object:  operands[0],
+			Bracket: bracket,
+			Start:   operands[1],
+			End:     operands[2],
+		}, nil
+	}
+	postfix := strings.HasPrefix(name, "post")
+	if postfix {
+		name = strings.TrimPrefix(name, "post")
+	}
+
+	operator, err := r.operator(name)
+	if err != nil {
+		return nil, err
+	}
+	switch operator.Type {
+	case token.PLUSPLUS, token.MINUSMINUS:
+		target, ok := operands[0].(expr.Target)
+		if len(operands) != 1 || !ok {
+			return nil, fmt.Errorf("'%s' expect one assignable operand", name)
+		}
+		return &expr.Increment{
+			Target:   target,
+			Operator: operator,
+			Postfix:  postfix,
+		}, nil
+	case token.EQUAL, token.PLUSEQUAL, token.MINUSEQUAL,
+		token.STAREQUAL, token.SLASHEQUAL, token.PERCENTEQUAL:
+		target, ok := operands[0].(expr.Target)
+		if len(operands) != 2 || !ok {
+			return nil, fmt.Errorf("'%s' expect assignable target and value", name)
+		}
+		return &expr.Assign{
+			Target:   target,
+			Operator: operator,
+			Value:    operands[1],
+		}, nil
+	}
+	if postfix {
+		return nil, fmt.Errorf("unknown operator 'post%s'", name)
+	}
+	switch len(operands) {
+	case 1:
+		return &expr.Unary{
+			Operator: operator,
+			Right:    operands[0],
+		}, nil
+	case 2:
+		return &expr.Binary{
+			Left:     operands[0],
+			Operator: operator,
+			Right:    operands[1],
+		}, nil
+	}
+	return nil, fmt.Errorf(
+		"'%s' expect 1 or 2 operands, got %d", name, len(operands))
+}
+
+// operator use scanner to get operator token, so reader and parser agree on token type
+func (r *Reader) operator(lexeme string) (token.Token, error) {
+	tokens, err := scanner.ScanLine(lexeme)
+	if err != nil {
+		return token.Token{}, err
+	}
+	if len(tokens) != 2 || tokens[0].Lexeme != lexeme ||
+		tokens[0].Type == token.IDENTIFIER {
+		return token.Token{}, fmt.Errorf("unknown operator '%s'", lexeme)
+	}
+	return tokens[0], nil
+}
+
+func (r *Reader) readString() (expr.Expr, error) {
+	start := r.current
+	r.current++
+	for !r.isAtEnd() && r.runes[r.current] != '"' {
+		r.current++
+	}
+	if r.isAtEnd() {
+		return nil, fmt.Errorf("[column %d] unterminated string", start)
+	}
+	r.current++
+	return &expr.Literal{
+		Value: value.String(string(r.runes[start+1 : r.current-1])),
+	}, nil
+}
+
+func (r *Reader) readAtom(word string) (expr.Expr, error) {
+	switch word {
+	case "true":
+		return &expr.Literal{Value: value.TrueValue}, nil
+	case "false":
+		return &expr.Literal{Value: value.FalseValue}, nil
+	case "nil":
+		return &expr.Literal{Value: value.NilValue}, nil
+	}
+	if !isNumber(word) {
+		return &expr.Variable{
+			Name: token.Token{
+				Type:   token.IDENTIFIER,
+				Lexeme: word,
+			},
+		}, nil
+	}
+	iValue, err := strconv.ParseInt(word, 10, 64)
+	if err == nil {
+		return &expr.Literal{Value: value.Int(iValue)}, nil
+	}
+	if bValue, ok := new(big.Int).SetString(strings.TrimSuffix(word, "n"), 10); ok &&
+		strings.HasSuffix(word, "n") {
+		return &expr.Literal{Value: value.BigInt(bValue)}, nil
+	}
+	if rValue, ok := new(big.Rat).SetString(strings.TrimSuffix(word, "d")); ok &&
+		strings.HasSuffix(word, "d") {
+		return &expr.Literal{Value: value.Decimal(rValue)}, nil
+	}
+	fValue, err := strconv.ParseFloat(word, 64)
+	if err != nil {
+		return nil, fmt.Errorf("[column %d] invalid number '%s'", r.current-len([]rune(word)), word)
+	}
+	return &expr.Literal{Value: value.Number(fValue)}, nil
+}
+
+// isNumber number starts with digit, or minus and digit for folded negative literal,
+// so identifiers like inf and nan, which strconv accepts, stay variables
+func isNumber(word string) bool {
+	word = strings.TrimPrefix(word, "-")
+	return word != "" && word[0] >= '0' && word[0] <= '9'
+}
+
+// readWord read chars until space or paren
+func (r *Reader) readWord() string {
+	start := r.current
+	for !r.isAtEnd() {
+		c := r.runes[r.current]
+		if unicode.IsSpace(c) || c == '(' || c == ')' {
+			break
+		}
+		r.current++
+	}
+	return string(r.runes[start:r.current])
+}
+
+func (r *Reader) skipSpace() {
+	for !r.isAtEnd() && unicode.IsSpace(r.runes[r.current]) {
+		r.current++
+	}
+}
+
+func (r *Reader) isAtEnd() bool {
+	return r.current >= len(r.runes)
+}
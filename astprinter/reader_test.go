@@ -0,0 +1,237 @@
+package astprinter_test
+
+import (
+	"fmt"
+	"learning/glox/astprinter"
+	"learning/glox/expr"
+	"learning/glox/parser"
+	"learning/glox/scanner"
+	"learning/glox/token"
+	"learning/glox/value"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, source string) (expr.Expr, bool) {
+	t.Helper()
+	tokens, err := scanner.ScanLine(source)
+	if err != nil {
+		return nil, false
+	}
+	e, err := parser.ParseLine(tokens)
+	if err != nil {
+		return nil, false
+	}
+	return e, true
+}
+
+// roundTrip check parse -> print -> read -> print gives the same s-expression
+func roundTrip(t *testing.T, source string) bool {
+	t.Helper()
+	e, ok := parse(t, source)
+	if !ok {
+		return false
+	}
+	printed := e.Visit()
+	read, err := astprinter.Read(printed)
+	if err != nil {
+		t.Errorf("Read(%q) of source %q: %v", printed, source, err)
+		return true
+	}
+	if read.Visit() != printed {
+		t.Errorf("source %q: printed %q, read back as %q", source, printed, read.Visit())
+	}
+	if diff := diffTree("", reflect.ValueOf(e), reflect.ValueOf(read)); diff != "" {
+		t.Errorf("source %q: read back tree differs at %s", source, diff)
+	}
+	if astprinter.RPN(read) != astprinter.RPN(e) {
+		t.Errorf("source %q: rpn %q, read back as %q", source, astprinter.RPN(e), astprinter.RPN(read))
+	}
+	return true
+}
+
+// diffTree path of the first difference of parsed tree a and read tree b, empty when
+// they are the same, token lines and scanner literals are ignored since s-expression
+// has no positions, and so is the number lexeme kept for the formatter
+func diffTree(path string, a, b reflect.Value) string {
+	if a.Kind() == reflect.Interface || a.Kind() == reflect.Ptr {
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				return fmt.Sprintf("%s: %v, read %v", path, a, b)
+			}
+			return ""
+		}
+		if a.Elem().Type() != b.Elem().Type() {
+			return fmt.Sprintf("%s: %s, read %s", path, a.Elem().Type(), b.Elem().Type())
+		}
+		return diffTree(path, a.Elem(), b.Elem())
+	}
+	switch a.Type() {
+	case reflect.TypeOf(token.Token{}):
+		ta, tb := a.Interface().(token.Token), b.Interface().(token.Token)
+		if ta.Type != tb.Type || ta.Lexeme != tb.Lexeme {
+			return fmt.Sprintf("%s: token %v %q, read %v %q", path, ta.Type, ta.Lexeme, tb.Type, tb.Lexeme)
+		}
+		return ""
+	case reflect.TypeOf(value.Value{}):
+		va, vb := a.Interface().(value.Value), b.Interface().(value.Value)
+		if va.TypeName() != vb.TypeName() || value.Stringify(va) != value.Stringify(vb) {
+			return fmt.Sprintf("%s: %s %s, read %s %s",
+				path, va.TypeName(), value.Stringify(va), vb.TypeName(), value.Stringify(vb))
+		}
+		return ""
+	}
+	switch a.Kind() {
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			name := a.Type().Field(i).Name
+			if a.Type() == reflect.TypeOf(expr.Literal{}) && name == "Lexeme" {
+				continue
+			}
+			diff := diffTree(path+"."+name, a.Field(i), b.Field(i))
+			if diff != "" {
+				return diff
+			}
+		}
+		return ""
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return fmt.Sprintf("%s: %d elements, read %d", path, a.Len(), b.Len())
+		}
+		for i := 0; i < a.Len(); i++ {
+			diff := diffTree(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i))
+			if diff != "" {
+				return diff
+			}
+		}
+		return ""
+	}
+	if a.Interface() != b.Interface() {
+		return fmt.Sprintf("%s: %v, read %v", path, a, b)
+	}
+	return ""
+}
+
+func TestReadRoundTrip(t *testing.T) {
+	sources := []string{
+		"1", "1.5", "1.0", "123n", "1.10d", `"a b (c)"`, "true", "false", "nil",
+		"x", "inf", "nan", "infinity", "NaN", "Infinity",
+		"-1", "!x", "~x", "!!true", "-(-1)", "(1 + 2) * 3", "1 - 2 - 3", "2 ** 3 ** 2",
+		"1 + 2 * 3 % 4 ~/ 5", "a & b | c ^ d", "1 << 2 >> 3", "1 < 2 == 2 >= 1",
+		"x in [1, 2]", "a ? b : c ? d : e", "1, 2, 3",
+		"[]", "[1, [2, 3]]", "{}", `{"a": 1, 2: [3]}`,
+		"f()", "len(x, y)", "f(1)(2)", "a[0]", "a[0][1]", "a[1:2]", "a[:2]", "a[1:]", "a[:]",
+		"a[0] = 1", "a[0] = b[1] = 2", "a[0] += 1", "a[0] -= 1", "a[0] *= 2", "a[0] /= 2", "a[0] %= 2",
+		"++a[0]", "--a[0]", "a[0]++", "a[0]--", "-a[0]++",
+	}
+	for _, source := range sources {
+		if !roundTrip(t, source) {
+			t.Errorf("source %q does not parse", source)
+		}
+	}
+}
+
+// gen random expression source of the whole grammar, operands are parenthesized
+// only sometimes, so precedence is exercised, sources which do not parse are skipped
+type gen struct {
+	rand *rand.Rand
+}
+
+var (
+	genAtoms = []string{
+		"0", "1", "42", "2.5", "1.0", "7n", "1.10d", `"s"`, `"a b"`,
+		"true", "false", "nil", "x", "len", "inf", "nan",
+	}
+	genBinary = []string{
+		"+", "-", "*", "/", "%", "~/", "**", "&", "|", "^", "<<", ">>",
+		"==", "!=", "<", "<=", ">", ">=", "in", ",",
+	}
+	genAssign = []string{"=", "+=", "-=", "*=", "/=", "%="}
+)
+
+func (g *gen) operand(depth int) string {
+	res := g.expr(depth)
+	if g.rand.Intn(2) == 0 {
+		return "(" + res + ")"
+	}
+	return res
+}
+
+func (g *gen) target(depth int) string {
+	return "a[" + g.expr(depth) + "]"
+}
+
+func (g *gen) list(depth int) string {
+	items := []string{}
+	for i := g.rand.Intn(3); i > 0; i-- {
+		items = append(items, g.operand(depth))
+	}
+	return strings.Join(items, ", ")
+}
+
+func (g *gen) expr(depth int) string {
+	if depth <= 0 {
+		return genAtoms[g.rand.Intn(len(genAtoms))]
+	}
+	depth--
+	switch g.rand.Intn(12) {
+	case 0:
+		return genAtoms[g.rand.Intn(len(genAtoms))]
+	case 1:
+		return []string{"-", "!", "~"}[g.rand.Intn(3)] + g.operand(depth)
+	case 2, 3:
+		return g.operand(depth) + " " + genBinary[g.rand.Intn(len(genBinary))] + " " + g.operand(depth)
+	case 4:
+		return g.operand(depth) + " ? " + g.operand(depth) + " : " + g.operand(depth)
+	case 5:
+		return "[" + g.list(depth) + "]"
+	case 6:
+		entries := []string{}
+		for i := g.rand.Intn(3); i > 0; i-- {
+			entries = append(entries, g.operand(depth)+": "+g.operand(depth))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	case 7:
+		return "f(" + g.list(depth) + ")"
+	case 8:
+		return g.target(depth)
+	case 9:
+		bounds := []string{"", g.operand(depth)}
+		return "a[" + bounds[g.rand.Intn(2)] + ":" + bounds[g.rand.Intn(2)] + "]"
+	case 10:
+		return g.target(depth) + " " + genAssign[g.rand.Intn(len(genAssign))] + " " + g.operand(depth)
+	}
+	if g.rand.Intn(2) == 0 {
+		return []string{"++", "--"}[g.rand.Intn(2)] + g.target(depth)
+	}
+	return g.target(depth) + []string{"++", "--"}[g.rand.Intn(2)]
+}
+
+func TestReadRoundTripGenerated(t *testing.T) {
+	g := gen{rand: rand.New(rand.NewSource(1))}
+	parsed := 0
+	for i := 0; i < 2000; i++ {
+		source := g.expr(4)
+		if roundTrip(t, source) {
+			parsed++
+		}
+	}
+	// most generated sources should parse, or the test checks little
+	if parsed < 1000 {
+		t.Errorf("only %d of 2000 generated sources parse", parsed)
+	}
+}
+
+func TestReadIdentifierLikeNumber(t *testing.T) {
+	for _, word := range []string{"inf", "nan", "infinity", "Inf", "NaN"} {
+		e, err := astprinter.Read(word)
+		if err != nil {
+			t.Fatalf("Read(%q): %v", word, err)
+		}
+		if _, ok := e.(*expr.Variable); !ok {
+			t.Errorf("Read(%q) = %s, want variable", word, fmt.Sprintf("%T", e))
+		}
+	}
+}
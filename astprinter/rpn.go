@@ -0,0 +1,69 @@
+package astprinter
+
+import (
+	"fmt"
+	"learning/glox/expr"
+	"learning/glox/token"
+	"strings"
+)
+
+// PrintRPN print expr in reverse polish notation
+func (ap AstPrinter) PrintRPN() {
+	fmt.Println("--rpn--", RPN(ap.Expr))
+}
+
+// RPN convert expr to reverse polish notation, eg: (1 + 2) * (4 - 3) => 1 2 + 4 3 - *
+// grouping only change evaluation order, so it disappear in rpn,
+// unary minus is written as `neg`, to distinguish from binary minus,
+// operator with variable operand count is suffixed with the count, eg: 1 2 list/2
+func RPN(e expr.Expr) string {
+	switch node := e.(type) {
+	case *expr.Call:
+		items := []string{RPN(node.Callee)}
+		for _, argument := range node.Arguments {
+			items = append(items, RPN(argument))
+		}
+		return joinRPN(append(items, fmt.Sprintf("call/%d", len(node.Arguments)))...)
+	case *expr.List:
+		items := []string{}
+		for _, element := range node.Elements {
+			items = append(items, RPN(element))
+		}
+		return joinRPN(append(items, fmt.Sprintf("list/%d", len(node.Elements)))...)
+	case *expr.Map:
+		items := []string{}
+		for i := range node.Keys {
+			items = append(items, RPN(node.Keys[i]), RPN(node.Values[i]))
+		}
+		return joinRPN(append(items, fmt.Sprintf("map/%d", len(node.Keys)))...)
+	case *expr.Index:
+		return joinRPN(RPN(node.Object), RPN(node.Index), "index")
+	case *expr.Slice:
+		return joinRPN(RPN(node.Object), RPN(node.Start), RPN(node.End), "slice")
+	case *expr.Assign:
+		return joinRPN(RPN(node.Target), RPN(node.Value), node.Operator.Lexeme)
+	case *expr.Increment:
+		operator := node.Operator.Lexeme
+		if node.Postfix {
+			operator = "post" + operator
+		}
+		return joinRPN(RPN(node.Target), operator)
+	case *expr.Binary:
+		return joinRPN(RPN(node.Left), RPN(node.Right), node.Operator.Lexeme)
+	case *expr.Conditional:
+		return joinRPN(RPN(node.Condition), RPN(node.ThenBranch), RPN(node.ElseBranch), "?:")
+	case *expr.Grouping:
+		return RPN(node.Expression)
+	case *expr.Unary:
+		operator := node.Operator.Lexeme
+		if node.Operator.Type == token.MINUS {
+			operator = "neg"
+		}
+		return joinRPN(RPN(node.Right), operator)
+	}
+	return e.Visit()
+}
+
+func joinRPN(items ...string) string {
+	return strings.Join(items, " ")
+}
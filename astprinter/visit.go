@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"learning/glox/expr"
 	"learning/glox/token"
+	"learning/glox/value"
 )
 
 // AstPrinter print expr ast
@@ -27,7 +28,7 @@ func StartPrint() {
 		Line:    1,
 	}
 	rightExpr := expr.Literal{
-		Value: 123,
+		Value: value.Int(123),
 	}
 	unary := expr.Unary{
 		Operator: iToken,
@@ -41,7 +42,7 @@ func StartPrint() {
 	}
 
 	groupExpr := expr.Literal{
-		Value: 45.67,
+		Value: value.Number(45.67),
 	}
 
 	group := expr.Grouping{
@@ -58,5 +59,16 @@ func StartPrint() {
 	}
 
 	astPrinter.Print()
+	astPrinter.PrintRPN()
+
+	// read printed ast back, it should print the same ast
+	readExpr, err := Read(expression.Visit())
+	if err != nil {
+		fmt.Println("--read-- err:", err)
+		return
+	}
+	AstPrinter{
+		Expr: readExpr,
+	}.Print()
 
 }
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"fmt"
+	"learning/glox/formatter"
+	"os"
+)
+
+func main() {
+	err := formatter.StartFormat(os.Args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,268 @@
+package expr
+
+import (
+	"fmt"
+	"learning/glox/token"
+	"learning/glox/value"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// maxBigShift largest shift count of bigint, larger shift would exhaust memory
+const maxBigShift = 1 << 16
+
+// maxBigPower largest bits of bigint or decimal `**` result, even without memory limit,
+// larger power would take too long, and could not be canceled
+const maxBigPower = 1 << 22
+
+// bigOperation apply arithmetic or comparison operator when either operand is bigint or decimal,
+// int and bigint promote to bigint, any of them with decimal promote to decimal,
+// bigint with float promote to float, decimal and float can not be mixed, except comparison
+func bigOperation(rt *Runtime, operator token.Token, left, right value.Value) (value.Value, error) {
+	_, _, err := numberOperands(operator, left, right)
+	if err != nil {
+		return value.NilValue, err
+	}
+
+	switch operator.Type {
+	case token.GREATER, token.GREATEREQUAL, token.LESS, token.LESSEQUAL:
+		return compare(operator, left, right), nil
+	}
+
+	if left.IsFloat() || right.IsFloat() {
+		_, lDecimal := left.Decimal()
+		_, rDecimal := right.Decimal()
+		if lDecimal || rDecimal {
+			return value.NilValue, &RuntimeError{
+				Token: operator,
+				Message: fmt.Sprintf(
+					"can not mix decimal and float, got %s and %s, convert with decimal() or float()",
+					left.TypeName(), right.TypeName()),
+			}
+		}
+		fLeft, _ := left.Number()
+		fRight, _ := right.Number()
+		return binaryOperation(rt, operator, value.Number(fLeft), value.Number(fRight))
+	}
+
+	_, lDecimal := left.Decimal()
+	_, rDecimal := right.Decimal()
+	if lDecimal || rDecimal {
+		return decimalOperation(rt, operator, left, right)
+	}
+	return bigIntOperation(rt, operator, toBigInt(left), toBigInt(right))
+}
+
+func bigIntOperation(rt *Runtime, operator token.Token, left, right *big.Int) (value.Value, error) {
+	switch operator.Type {
+	case token.PLUS:
+		return value.BigInt(new(big.Int).Add(left, right)), nil
+	case token.MINUS:
+		return value.BigInt(new(big.Int).Sub(left, right)), nil
+	case token.STAR:
+		return value.BigInt(new(big.Int).Mul(left, right)), nil
+	case token.SLASH:
+		// exact, like decimal division
+		if right.Sign() == 0 {
+			return value.NilValue, divisionByZero(operator)
+		}
+		return value.Decimal(new(big.Rat).SetFrac(left, right)), nil
+	case token.PERCENT, token.TILDESLASH:
+		if right.Sign() == 0 {
+			return value.NilValue, divisionByZero(operator)
+		}
+		// truncate toward zero, remainder has the sign of left, like int
+		quotient, remainder := new(big.Int).QuoRem(left, right, new(big.Int))
+		if operator.Type == token.PERCENT {
+			return value.BigInt(remainder), nil
+		}
+		return value.BigInt(quotient), nil
+	case token.STARSTAR:
+		if right.Sign() < 0 {
+			return decimalOperation(rt, operator,
+				value.Decimal(new(big.Rat).SetInt(left)), value.BigInt(right))
+		}
+		err := allocatePower(rt, operator, powerBits(right, left))
+		if err != nil {
+			return value.NilValue, err
+		}
+		return value.BigInt(new(big.Int).Exp(left, right, nil)), nil
+	}
+	return value.NilValue, nil
+}
+
+func decimalOperation(rt *Runtime, operator token.Token, left, right value.Value) (value.Value, error) {
+	rLeft, _ := left.Rat()
+	rRight, _ := right.Rat()
+	switch operator.Type {
+	case token.PLUS:
+		return value.Decimal(new(big.Rat).Add(rLeft, rRight)), nil
+	case token.MINUS:
+		return value.Decimal(new(big.Rat).Sub(rLeft, rRight)), nil
+	case token.STAR:
+		return value.Decimal(new(big.Rat).Mul(rLeft, rRight)), nil
+	case token.SLASH, token.PERCENT, token.TILDESLASH:
+		if rRight.Sign() == 0 {
+			return value.NilValue, divisionByZero(operator)
+		}
+		quotient := new(big.Rat).Quo(rLeft, rRight)
+		if operator.Type == token.SLASH {
+			return value.Decimal(quotient), nil
+		}
+		// truncate toward zero, remainder has the sign of left, like int
+		truncated := new(big.Rat).SetInt(new(big.Int).Quo(quotient.Num(), quotient.Denom()))
+		if operator.Type == token.TILDESLASH {
+			return value.Decimal(truncated), nil
+		}
+		return value.Decimal(new(big.Rat).Sub(rLeft, truncated.Mul(truncated, rRight))), nil
+	case token.STARSTAR:
+		if !rRight.IsInt() {
+			return value.NilValue, &RuntimeError{
+				Token:   operator,
+				Message: "decimal exponent must be an integer, got " + value.Stringify(right),
+			}
+		}
+		exponent := rRight.Num()
+		if exponent.Sign() < 0 && rLeft.Sign() == 0 {
+			return value.NilValue, divisionByZero(operator)
+		}
+		// (a/b)**n = a**n / b**n, inverted for negative n
+		n := new(big.Int).Abs(exponent)
+		err := allocatePower(rt, operator, powerBits(n, rLeft.Num(), rLeft.Denom()))
+		if err != nil {
+			return value.NilValue, err
+		}
+		numerator := new(big.Int).Exp(rLeft.Num(), n, nil)
+		denominator := new(big.Int).Exp(rLeft.Denom(), n, nil)
+		if exponent.Sign() < 0 {
+			numerator, denominator = denominator, numerator
+		}
+		return value.Decimal(new(big.Rat).SetFrac(numerator, denominator)), nil
+	}
+	return value.NilValue, nil
+}
+
+// powerBits lower bound of bits of every base ** exponent together, exponent is not negative,
+// bases 0, 1 and -1 count nothing, saturates at math.MaxInt32
+func powerBits(exponent *big.Int, bases ...*big.Int) int {
+	baseBits := int64(0)
+	for _, base := range bases {
+		if base.BitLen() > 1 {
+			baseBits += int64(base.BitLen() - 1)
+		}
+	}
+	bits := new(big.Int).Mul(big.NewInt(baseBits), exponent)
+	if !bits.IsInt64() || bits.Int64() > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(bits.Int64())
+}
+
+// allocatePower count `**` result of bits before it is computed, Exp can not be canceled,
+// and fail when it is larger than maxBigPower
+func allocatePower(rt *Runtime, operator token.Token, bits int) error {
+	err := rt.allocate(operator, bits/8)
+	if err != nil {
+		return err
+	}
+	if bits > maxBigPower {
+		return &RuntimeError{
+			Token:   operator,
+			Message: fmt.Sprintf("power result is too large, more than %d bits", maxBigPower),
+		}
+	}
+	return nil
+}
+
+// bigBitwise evaluate & | ^ << >> when either operand is bigint, shift count must be an int
+func bigBitwise(operator token.Token, left, right value.Value) (value.Value, error) {
+	bLeft, err := bigInteger(operator, left)
+	if err != nil {
+		return value.NilValue, err
+	}
+	bRight, err := bigInteger(operator, right)
+	if err != nil {
+		return value.NilValue, err
+	}
+	switch operator.Type {
+	case token.AMPERSAND:
+		return value.BigInt(new(big.Int).And(bLeft, bRight)), nil
+	case token.PIPE:
+		return value.BigInt(new(big.Int).Or(bLeft, bRight)), nil
+	case token.CARET:
+		return value.BigInt(new(big.Int).Xor(bLeft, bRight)), nil
+	}
+	if bRight.Sign() < 0 || bRight.Cmp(big.NewInt(maxBigShift)) > 0 {
+		return value.NilValue, &RuntimeError{
+			Token:   operator,
+			Message: fmt.Sprintf("shift count must be in [0, %d], got %s", maxBigShift, bRight),
+		}
+	}
+	if operator.Type == token.LESSLESS {
+		return value.BigInt(new(big.Int).Lsh(bLeft, uint(bRight.Int64()))), nil
+	}
+	return value.BigInt(new(big.Int).Rsh(bLeft, uint(bRight.Int64()))), nil
+}
+
+// bigInteger get *big.Int operand of bitwise operator
+func bigInteger(operator token.Token, operand value.Value) (*big.Int, error) {
+	if bValue, ok := operand.BigInt(); ok {
+		return bValue, nil
+	}
+	iValue, err := integer(operator, operand)
+	if err != nil {
+		return nil, err
+	}
+	return big.NewInt(iValue), nil
+}
+
+// toBigInt convert int or bigint to *big.Int
+func toBigInt(v value.Value) *big.Int {
+	if bValue, ok := v.BigInt(); ok {
+		return bValue
+	}
+	iValue, _ := v.Int()
+	return big.NewInt(iValue)
+}
+
+func divisionByZero(operator token.Token) *RuntimeError {
+	return &RuntimeError{
+		Token:   operator,
+		Message: "division by zero",
+	}
+}
+
+func nativeBigInt(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	if _, ok := arguments[0].BigInt(); ok {
+		return arguments[0], nil
+	}
+	if iValue, ok := arguments[0].Int(); ok {
+		return value.BigInt(big.NewInt(iValue)), nil
+	}
+	rValue, ok := arguments[0].Rat()
+	if !ok {
+		return value.NilValue, &RuntimeError{
+			Token:   paren,
+			Message: "bigint() argument must be a finite number, got " + value.Stringify(arguments[0]),
+		}
+	}
+	// truncate toward zero
+	return value.BigInt(new(big.Int).Quo(rValue.Num(), rValue.Denom())), nil
+}
+
+func nativeDecimal(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	if fValue, ok := arguments[0].Number(); ok && arguments[0].IsFloat() {
+		// shortest decimal which reads back as the float, decimal(0.1) is 0.1d
+		rValue, ok := new(big.Rat).SetString(strconv.FormatFloat(fValue, 'g', -1, 64))
+		if !ok {
+			return value.NilValue, &RuntimeError{
+				Token:   paren,
+				Message: "decimal() argument must be a finite number, got " + value.Stringify(arguments[0]),
+			}
+		}
+		return value.Decimal(rValue), nil
+	}
+	rValue, _ := arguments[0].Rat()
+	return value.Decimal(rValue), nil
+}
@@ -0,0 +1,91 @@
+package expr
+
+import (
+	"io"
+	"learning/glox/token"
+	"learning/glox/value"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nativeClock seconds since unix epoch, as float
+func nativeClock(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	return value.Number(float64(time.Now().UnixNano()) / float64(time.Second)), nil
+}
+
+// nativeStr print value like the interpreter does
+func nativeStr(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	return value.String(value.Stringify(arguments[0])), nil
+}
+
+// nativeNum parse string as int, or float when it is not integral, numbers are returned as is
+func nativeNum(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	str, ok := arguments[0].Str()
+	if !ok {
+		return arguments[0], nil
+	}
+	str = strings.TrimSpace(str)
+	if iValue, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return value.Int(iValue), nil
+	}
+	// only decimal numbers, ParseFloat also takes "inf", "0x1p4" and "1_000"
+	if strings.IndexFunc(str, notDecimal) < 0 {
+		if fValue, err := strconv.ParseFloat(str, 64); err == nil {
+			return value.Number(fValue), nil
+		}
+	}
+	return value.NilValue, &RuntimeError{
+		Token:   paren,
+		Message: "num() can not convert " + strconv.Quote(str) + " to number",
+	}
+}
+
+func notDecimal(r rune) bool {
+	return !(r >= '0' && r <= '9') && !strings.ContainsRune("+-.eE", r)
+}
+
+// nativeType type name of value, like "int" and "list"
+func nativeType(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	return value.String(arguments[0].TypeName()), nil
+}
+
+// nativeArity number of arguments function takes
+func nativeArity(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	object, _ := arguments[0].Object()
+	function, ok := object.(Callable)
+	if !ok {
+		return value.NilValue, &RuntimeError{
+			Token:   paren,
+			Message: "arity() argument must be callable, got " + arguments[0].TypeName(),
+		}
+	}
+	return value.Int(int64(function.Arity())), nil
+}
+
+// nativeInput read a line from stdin of runtime without line ending,
+// nil at end of input, or when runtime has no stdin
+func nativeInput(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	if rt == nil || rt.stdin == nil {
+		return value.NilValue, nil
+	}
+	line, err := rt.stdin.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return value.NilValue, nil
+	}
+	if err != nil && err != io.EOF {
+		return value.NilValue, &RuntimeError{
+			Token:   paren,
+			Message: "input() " + err.Error(),
+		}
+	}
+	return value.String(strings.TrimRight(line, "\r\n")), nil
+}
+
+// nativeExit stop the interpreter with code
+func nativeExit(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	code, _ := arguments[0].Int()
+	return value.NilValue, &ExitError{
+		Code: int(code),
+	}
+}
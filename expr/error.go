@@ -0,0 +1,35 @@
+package expr
+
+import (
+	"fmt"
+	"learning/glox/token"
+)
+
+// RuntimeError error raised when evaluating expr, located by token
+type RuntimeError struct {
+	Token   token.Token // where the error happens
+	Message string      // error message
+	Err     error       // cause, like ErrStackOverflow, nil for most errors
+}
+
+// ExitError returned by exit(code), the interpreter stops with the code
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit with code %d", e.Code)
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf(
+		"[line %d] Error at '%s': %s",
+		e.Token.Line,
+		e.Token.Lexeme,
+		e.Message)
+}
+
+// Unwrap cause of error, for errors.Is
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
@@ -3,13 +3,22 @@ package expr
 import (
 	"fmt"
 	"learning/glox/token"
-	"learning/glox/utils"
+	"learning/glox/value"
+	"math"
+	"math/big"
 )
 
 // Expr interface{} implement visit() method, to print ast
 type Expr interface {
 	Visit() string
-	Evaluate() (interface{}, error)
+	Evaluate(rt *Runtime) (value.Value, error)
+}
+
+// Assign assign expr, target = value, or compound target += value
+type Assign struct {
+	Target   Target
+	Operator token.Token // = += -= *= /= %=
+	Value    Expr
 }
 
 // Binary binary expr
@@ -19,14 +28,65 @@ type Binary struct {
 	Right    Expr
 }
 
+// Call call expr, callee(arguments...)
+type Call struct {
+	Callee    Expr
+	Paren     token.Token // right paren, for error location
+	Arguments []Expr
+}
+
+// Conditional conditional expr, cond ? a : b
+type Conditional struct {
+	Condition  Expr
+	ThenBranch Expr
+	ElseBranch Expr
+}
+
 // Grouping grouping expr
 type Grouping struct {
+	Paren      token.Token // left paren, for error location
 	Expression Expr
 }
 
+// Increment increment expr, ++target, target++, --target or target--
+type Increment struct {
+	Target   Target
+	Operator token.Token // ++ or --
+	Postfix  bool        // postfix evaluates to the old value
+}
+
+// Index index expr, object[index]
+type Index struct {
+	Object  Expr
+	Bracket token.Token // left bracket, for error location
+	Index   Expr
+}
+
+// List list literal expr, [elements...]
+type List struct {
+	Bracket  token.Token // left bracket, for error location
+	Elements []Expr
+}
+
+// Map map literal expr, {key: value...}
+type Map struct {
+	Brace  token.Token // left brace, for error location
+	Keys   []Expr
+	Values []Expr
+}
+
 // Literal literal expr
 type Literal struct {
-	Value interface{}
+	Value  value.Value
+	Lexeme string // source text of number literal, kept by formatter, empty when folded or read
+}
+
+// Slice slice expr, object[start:end], missing bound is nil literal
+type Slice struct {
+	Object  Expr
+	Bracket token.Token // left bracket, for error location
+	Start   Expr
+	End     Expr
 }
 
 // Unary unary expr
@@ -40,135 +100,270 @@ type Variable struct {
 	Name token.Token
 }
 
+// Visit assign expr implement visit method
+func (a *Assign) Visit() string {
+	return parenthesize(a.Operator.Lexeme, a.Target, a.Value)
+}
+
+// Evaluate assign expr implement evaluate method, target receiver and index are
+// evaluated once, even for compound assign, result is the assigned value
+func (a *Assign) Evaluate(rt *Runtime) (value.Value, error) {
+	err := rt.step(a.Operator)
+	if err != nil {
+		return value.NilValue, err
+	}
+	location, err := a.Target.Locate(rt)
+	if err != nil {
+		return value.NilValue, err
+	}
+	var current value.Value
+	if a.Operator.Type != token.EQUAL {
+		current, err = location.Get()
+		if err != nil {
+			return value.NilValue, err
+		}
+	}
+	res, err := a.Value.Evaluate(rt)
+	if err != nil {
+		return value.NilValue, err
+	}
+	if a.Operator.Type != token.EQUAL {
+		res, err = binaryOperation(rt, compoundOperator(a.Operator), current, res)
+		if err != nil {
+			return value.NilValue, err
+		}
+		err = rt.allocate(a.Operator, sizeOf(res))
+		if err != nil {
+			return value.NilValue, err
+		}
+	}
+	err = location.Set(res)
+	if err != nil {
+		return value.NilValue, err
+	}
+	return res, nil
+}
+
 // Visit binary expr implement visit method
 func (b *Binary) Visit() string {
 	return parenthesize(b.Operator.Lexeme, b.Left, b.Right)
 }
 
 // Evaluate binary expr implement evaluate method
-func (b *Binary) Evaluate() (interface{}, error) {
-	left, err := b.Left.Evaluate()
+func (b *Binary) Evaluate(rt *Runtime) (value.Value, error) {
+	err := rt.step(b.Operator)
+	if err != nil {
+		return value.NilValue, err
+	}
+
+	left, err := b.Left.Evaluate(rt)
 	if err != nil {
 		return left, err
 	}
 
-	right, err := b.Right.Evaluate()
+	right, err := b.Right.Evaluate(rt)
 	if err != nil {
 		return right, err
 	}
 
-	switch b.Operator.Type {
+	res, err := binaryOperation(rt, b.Operator, left, right)
+	if err != nil || b.Operator.Type == token.COMMA {
+		// comma creates nothing, right is counted already
+		return res, err
+	}
+	return res, rt.allocate(b.Operator, sizeOf(res))
+}
+
+// binaryOperation apply binary operator to evaluated operands, rt limits big results
+func binaryOperation(rt *Runtime, operator token.Token, left, right value.Value) (value.Value, error) {
+	switch operator.Type {
+
+	case token.COMMA:
+		return right, nil
+
+	case token.IN:
+		return contains(operator, left, right)
 
 	case token.BANGEQUAL:
-		return !isEqual(left, right), nil
+		return value.Bool(!value.Equals(left, right)), nil
 
 	case token.EQUALEQUAL:
-		return isEqual(left, right), nil
+		return value.Bool(value.Equals(left, right)), nil
 
-	case token.GREATER:
-		fLeft, err := utils.GetFloatNumber(left)
-		if err != nil {
-			return fLeft, err
-		}
-		fRight, err := utils.GetFloatNumber(right)
-		if err != nil {
-			return fRight, err
-		}
-		return fLeft > fRight, nil
+	case token.AMPERSAND, token.PIPE, token.CARET, token.LESSLESS, token.GREATERGREATER:
+		return bitwise(operator, left, right)
 
-	case token.GREATEREQUAL:
-		fLeft, err := utils.GetFloatNumber(left)
-		if err != nil {
-			return fLeft, err
+	case token.PLUS:
+		sLeft, lok := left.Str()
+		sRight, rok := right.Str()
+		if lok && rok {
+			return value.String(sLeft + sRight), nil
 		}
-		fRight, err := utils.GetFloatNumber(right)
-		if err != nil {
-			return fRight, err
+		if lok || rok {
+			return value.NilValue, &RuntimeError{
+				Token: operator,
+				Message: fmt.Sprintf(
+					"operands must be two numbers or two strings, got %s and %s",
+					left.TypeName(), right.TypeName()),
+			}
 		}
-		return fLeft >= fRight, nil
+	}
 
-	case token.LESS:
-		fLeft, err := utils.GetFloatNumber(left)
-		if err != nil {
-			return fLeft, err
-		}
-		fRight, err := utils.GetFloatNumber(right)
-		if err != nil {
-			return fRight, err
-		}
-		return fLeft < fRight, nil
+	if left.IsBig() || right.IsBig() {
+		return bigOperation(rt, operator, left, right)
+	}
 
-	case token.LESSEQUAL:
-		fLeft, err := utils.GetFloatNumber(left)
-		if err != nil {
-			return fLeft, err
-		}
-		fRight, err := utils.GetFloatNumber(right)
-		if err != nil {
-			return fRight, err
-		}
-		return fLeft <= fRight, nil
+	iLeft, lok := left.Int()
+	iRight, rok := right.Int()
+	if lok && rok {
+		return intOperation(operator, iLeft, iRight)
+	}
+
+	// mixed int and float promote to float, except comparison
+	fLeft, fRight, err := numberOperands(operator, left, right)
+	if err != nil {
+		return value.NilValue, err
+	}
+
+	switch operator.Type {
+
+	case token.GREATER, token.GREATEREQUAL, token.LESS, token.LESSEQUAL:
+		// exactly like ==, int converted to float would lose digits
+		return compare(operator, left, right), nil
+
+	case token.PLUS:
+		return value.Number(fLeft + fRight), nil
 
 	case token.MINUS:
-		fLeft, err := utils.GetFloatNumber(left)
-		if err != nil {
-			return fLeft, err
-		}
-		fRight, err := utils.GetFloatNumber(right)
-		if err != nil {
-			return fRight, err
-		}
-		return fLeft - fRight, nil
+		return value.Number(fLeft - fRight), nil
 
 	case token.SLASH:
-		fLeft, err := utils.GetFloatNumber(left)
-		if err != nil {
-			return fLeft, err
-		}
-		fRight, err := utils.GetFloatNumber(right)
-		if err != nil {
-			return fRight, err
-		}
-		return fLeft / fRight, nil
+		return value.Number(fLeft / fRight), nil
 
 	case token.STAR:
-		fLeft, err := utils.GetFloatNumber(left)
-		if err != nil {
-			return fLeft, err
+		return value.Number(fLeft * fRight), nil
+
+	case token.PERCENT:
+		// like c fmod, result has the sign of left
+		return value.Number(math.Mod(fLeft, fRight)), nil
+
+	case token.STARSTAR:
+		return value.Number(math.Pow(fLeft, fRight)), nil
+
+	case token.TILDESLASH:
+		if fRight == 0 {
+			return value.NilValue, &RuntimeError{
+				Token:   operator,
+				Message: "integer division by zero",
+			}
 		}
-		fRight, err := utils.GetFloatNumber(right)
-		if err != nil {
-			return fRight, err
+		// truncate toward zero
+		return value.Number(math.Trunc(fLeft / fRight)), nil
+
+	}
+
+	return value.NilValue, nil
+}
+
+// compare apply comparison operator to two numbers of any kind exactly,
+// NaN is not ordered, every comparison with it is false
+func compare(operator token.Token, left, right value.Value) value.Value {
+	res, ok := value.Compare(left, right)
+	if !ok {
+		return value.FalseValue
+	}
+	switch operator.Type {
+	case token.GREATER:
+		return value.Bool(res > 0)
+	case token.GREATEREQUAL:
+		return value.Bool(res >= 0)
+	case token.LESS:
+		return value.Bool(res < 0)
+	}
+	return value.Bool(res <= 0)
+}
+
+// numberOperands get operands of arithmetic or comparison operator, both must be numbers
+func numberOperands(operator token.Token, left, right value.Value) (float64, float64, error) {
+	fLeft, lok := left.Number()
+	fRight, rok := right.Number()
+	if !lok || !rok {
+		return 0, 0, &RuntimeError{
+			Token: operator,
+			Message: fmt.Sprintf(
+				"operands must be numbers, got %s and %s", left.TypeName(), right.TypeName()),
 		}
-		return fLeft * fRight, nil
+	}
+	return fLeft, fRight, nil
+}
 
-	case token.PLUS:
+// Visit call expr implement visit method
+func (c *Call) Visit() string {
+	return parenthesize("call", append([]Expr{c.Callee}, c.Arguments...)...)
+}
 
-		switch left.(type) {
-		case string:
-			sLeft := left.(string)
-			sRight, ok := right.(string)
-			if !ok {
-				return nil, fmt.Errorf(
-					"plus operator should be two string, or two float")
-			}
+// Evaluate call expr implement evaluate method
+func (c *Call) Evaluate(rt *Runtime) (value.Value, error) {
+	err := rt.step(c.Paren)
+	if err != nil {
+		return value.NilValue, err
+	}
+	err = rt.enter(c.Paren)
+	if err != nil {
+		return value.NilValue, err
+	}
+	defer rt.leave()
 
-			return sLeft + sRight, nil
-		default:
-			fLeft, err := utils.GetFloatNumber(left)
-			if err != nil {
-				return nil, err
-			}
-			fRight, err := utils.GetFloatNumber(right)
-			if err != nil {
-				return nil, err
-			}
-			return fLeft + fRight, nil
+	callee, err := c.Callee.Evaluate(rt)
+	if err != nil {
+		return value.NilValue, err
+	}
+	arguments := []value.Value{}
+	for _, argument := range c.Arguments {
+		res, err := argument.Evaluate(rt)
+		if err != nil {
+			return value.NilValue, err
 		}
+		arguments = append(arguments, res)
+	}
 
+	object, _ := callee.Object()
+	function, ok := object.(Callable)
+	if !ok {
+		return value.NilValue, &RuntimeError{
+			Token:   c.Paren,
+			Message: "can only call functions, got " + callee.TypeName(),
+		}
 	}
+	if len(arguments) != function.Arity() {
+		return value.NilValue, &RuntimeError{
+			Token: c.Paren,
+			Message: fmt.Sprintf(
+				"expected %d arguments but got %d", function.Arity(), len(arguments)),
+		}
+	}
+	res, err := function.Call(rt, c.Paren, arguments)
+	if err != nil {
+		return value.NilValue, err
+	}
+	return res, rt.allocate(c.Paren, sizeOf(res))
+}
 
-	return nil, nil
+// Visit conditional expr implement visit method
+func (c *Conditional) Visit() string {
+	return parenthesize("?:", c.Condition, c.ThenBranch, c.ElseBranch)
+}
+
+// Evaluate conditional expr implement evaluate method, only one branch is evaluated
+func (c *Conditional) Evaluate(rt *Runtime) (value.Value, error) {
+	condition, err := c.Condition.Evaluate(rt)
+	if err != nil {
+		return condition, err
+	}
+	if condition.Truthy() {
+		return c.ThenBranch.Evaluate(rt)
+	}
+	return c.ElseBranch.Evaluate(rt)
 }
 
 // Visit grouping expr implement visit method
@@ -177,45 +372,263 @@ func (g *Grouping) Visit() string {
 }
 
 // Evaluate grouping expr implement evaluate method
-func (g *Grouping) Evaluate() (interface{}, error) {
-	return g.Expression.Evaluate()
+func (g *Grouping) Evaluate(rt *Runtime) (value.Value, error) {
+	err := rt.enter(g.Paren)
+	if err != nil {
+		return value.NilValue, err
+	}
+	defer rt.leave()
+	return g.Expression.Evaluate(rt)
+}
+
+// Visit increment expr implement visit method, postfix is printed as post++ or post--
+func (i *Increment) Visit() string {
+	if i.Postfix {
+		return parenthesize("post"+i.Operator.Lexeme, i.Target)
+	}
+	return parenthesize(i.Operator.Lexeme, i.Target)
+}
+
+// Evaluate increment expr implement evaluate method, target must be a number
+func (i *Increment) Evaluate(rt *Runtime) (value.Value, error) {
+	err := rt.step(i.Operator)
+	if err != nil {
+		return value.NilValue, err
+	}
+	location, err := i.Target.Locate(rt)
+	if err != nil {
+		return value.NilValue, err
+	}
+	current, err := location.Get()
+	if err != nil {
+		return value.NilValue, err
+	}
+	if _, ok := current.Number(); !ok {
+		return value.NilValue, &RuntimeError{
+			Token:   i.Operator,
+			Message: "operand of '" + i.Operator.Lexeme + "' must be a number, got " + current.TypeName(),
+		}
+	}
+	res, err := binaryOperation(rt, compoundOperator(i.Operator), current, value.Int(1))
+	if err != nil {
+		return value.NilValue, err
+	}
+	err = location.Set(res)
+	if err != nil {
+		return value.NilValue, err
+	}
+	if i.Postfix {
+		return current, nil
+	}
+	return res, nil
+}
+
+// Visit index expr implement visit method
+func (i *Index) Visit() string {
+	return parenthesize("index", i.Object, i.Index)
+}
+
+// Evaluate index expr implement evaluate method, negative list index count from the end
+func (i *Index) Evaluate(rt *Runtime) (value.Value, error) {
+	err := rt.step(i.Bracket)
+	if err != nil {
+		return value.NilValue, err
+	}
+	location, err := i.Locate(rt)
+	if err != nil {
+		return value.NilValue, err
+	}
+	return location.Get()
+}
+
+// Visit list expr implement visit method
+func (l *List) Visit() string {
+	return parenthesize("list", l.Elements...)
+}
+
+// Evaluate list expr implement evaluate method
+func (l *List) Evaluate(rt *Runtime) (value.Value, error) {
+	err := rt.step(l.Bracket)
+	if err != nil {
+		return value.NilValue, err
+	}
+	err = rt.allocate(l.Bracket, len(l.Elements)*valueSize)
+	if err != nil {
+		return value.NilValue, err
+	}
+	elements := []value.Value{}
+	for _, element := range l.Elements {
+		res, err := element.Evaluate(rt)
+		if err != nil {
+			return value.NilValue, err
+		}
+		elements = append(elements, res)
+	}
+	return value.FromObject(&LoxList{
+		Elements: elements,
+	}), nil
+}
+
+// Visit map expr implement visit method, keys and values are interleaved
+func (m *Map) Visit() string {
+	entries := []Expr{}
+	for i := range m.Keys {
+		entries = append(entries, m.Keys[i], m.Values[i])
+	}
+	return parenthesize("map", entries...)
+}
+
+// Evaluate map expr implement evaluate method
+func (m *Map) Evaluate(rt *Runtime) (value.Value, error) {
+	err := rt.step(m.Brace)
+	if err != nil {
+		return value.NilValue, err
+	}
+	err = rt.allocate(m.Brace, len(m.Keys)*2*valueSize)
+	if err != nil {
+		return value.NilValue, err
+	}
+	res := NewLoxMap()
+	for i := range m.Keys {
+		key, err := m.Keys[i].Evaluate(rt)
+		if err != nil {
+			return value.NilValue, err
+		}
+		entry, err := m.Values[i].Evaluate(rt)
+		if err != nil {
+			return value.NilValue, err
+		}
+		err = res.set(m.Brace, key, entry)
+		if err != nil {
+			return value.NilValue, err
+		}
+	}
+	return value.FromObject(res), nil
 }
 
 // Visit literal expr implement visit method
 func (l *Literal) Visit() string {
-	if l.Value == nil {
-		return "nil"
+	if str, ok := l.Value.Str(); ok {
+		return "\"" + str + "\""
 	}
-	return fmt.Sprintf("%v", l.Value)
+	return value.Stringify(l.Value) + numberSuffix(l.Value)
+}
+
+// numberSuffix literal suffix of bigint and decimal, so they read back as the same kind
+func numberSuffix(v value.Value) string {
+	switch v.Kind() {
+	case value.BigIntKind:
+		return "n"
+	case value.DecimalKind:
+		return "d"
+	}
+	return ""
 }
 
 // Evaluate literal expr implement evaluate method
-func (l *Literal) Evaluate() (interface{}, error) {
+func (l *Literal) Evaluate(rt *Runtime) (value.Value, error) {
 	return l.Value, nil
 }
 
+// Visit slice expr implement visit method
+func (s *Slice) Visit() string {
+	return parenthesize("slice", s.Object, s.Start, s.End)
+}
+
+// Evaluate slice expr implement evaluate method, bounds are clamped to the list,
+// nil bound means from start or to end
+func (s *Slice) Evaluate(rt *Runtime) (value.Value, error) {
+	err := rt.step(s.Bracket)
+	if err != nil {
+		return value.NilValue, err
+	}
+	object, err := s.Object.Evaluate(rt)
+	if err != nil {
+		return value.NilValue, err
+	}
+	list, ok := asList(object)
+	if !ok {
+		return value.NilValue, &RuntimeError{
+			Token:   s.Bracket,
+			Message: "only lists can be sliced, got " + object.TypeName(),
+		}
+	}
+	start, err := list.bound(rt, s.Bracket, s.Start, 0)
+	if err != nil {
+		return value.NilValue, err
+	}
+	end, err := list.bound(rt, s.Bracket, s.End, len(list.Elements))
+	if err != nil {
+		return value.NilValue, err
+	}
+	elements := []value.Value{}
+	if start < end {
+		err = rt.allocate(s.Bracket, (end-start)*valueSize)
+		if err != nil {
+			return value.NilValue, err
+		}
+		elements = append(elements, list.Elements[start:end]...)
+	}
+	return value.FromObject(&LoxList{
+		Elements: elements,
+	}), nil
+}
+
 // Visit unary expr implement visit method
 func (u *Unary) Visit() string {
 	return parenthesize(u.Operator.Lexeme, u.Right)
 }
 
 // Evaluate unary expr implement evaluate method
-func (u *Unary) Evaluate() (interface{}, error) {
-	right, err := u.Right.Evaluate()
+func (u *Unary) Evaluate(rt *Runtime) (value.Value, error) {
+	err := rt.step(u.Operator)
+	if err != nil {
+		return value.NilValue, err
+	}
+	err = rt.enter(u.Operator)
+	if err != nil {
+		return value.NilValue, err
+	}
+	right, err := u.Right.Evaluate(rt)
+	rt.leave()
 	if err != nil {
 		return right, err
 	}
 	switch u.Operator.Type {
 	case token.BANG:
-		return !isTruthy(right), nil
+		return value.Bool(!right.Truthy()), nil
 	case token.MINUS:
-		fNumber, err := utils.GetFloatNumber(right)
+		if iNumber, ok := right.Int(); ok {
+			if iNumber == math.MinInt64 {
+				return value.NilValue, overflow(u.Operator)
+			}
+			return value.Int(-iNumber), nil
+		}
+		if bNumber, ok := right.BigInt(); ok {
+			return value.BigInt(new(big.Int).Neg(bNumber)), nil
+		}
+		if rNumber, ok := right.Decimal(); ok {
+			return value.Decimal(new(big.Rat).Neg(rNumber)), nil
+		}
+		fNumber, ok := right.Number()
+		if !ok {
+			return value.NilValue, &RuntimeError{
+				Token:   u.Operator,
+				Message: "operand must be a number, got " + right.TypeName(),
+			}
+		}
+		return value.Number(-fNumber), nil
+	case token.TILDE:
+		if bNumber, ok := right.BigInt(); ok {
+			return value.BigInt(new(big.Int).Not(bNumber)), nil
+		}
+		iNumber, err := integer(u.Operator, right)
 		if err != nil {
-			return fNumber, err
+			return value.NilValue, err
 		}
-		return -1 * fNumber, nil
+		return value.Int(^iNumber), nil
 	}
-	return nil, nil
+	return value.NilValue, nil
 }
 
 // Visit variable expr implement visit method
@@ -223,6 +636,22 @@ func (v *Variable) Visit() string {
 	return v.Name.Lexeme
 }
 
+// Evaluate variable expr implement evaluate method, only globals are defined for now
+func (v *Variable) Evaluate(rt *Runtime) (value.Value, error) {
+	err := rt.step(v.Name)
+	if err != nil {
+		return value.NilValue, err
+	}
+	res, ok := Globals[v.Name.Lexeme]
+	if !ok {
+		return value.NilValue, &RuntimeError{
+			Token:   v.Name,
+			Message: fmt.Sprintf("undefined variable '%s'", v.Name.Lexeme),
+		}
+	}
+	return res, nil
+}
+
 func parenthesize(name string, exprs ...Expr) string {
 	res := ""
 	res += "("
@@ -236,17 +665,69 @@ func parenthesize(name string, exprs ...Expr) string {
 
 }
 
-func isTruthy(data interface{}) bool {
-	if data == nil {
-		return false
+// integer get int64 operand of bitwise operator, int or integral float
+func integer(operator token.Token, operand value.Value) (int64, error) {
+	if iValue, ok := operand.Int(); ok {
+		return iValue, nil
 	}
-	bData, ok := data.(bool)
-	if ok {
-		return bData
+	fValue, ok := operand.Number()
+	iValue, integral := value.IntOf(fValue)
+	if !ok || !integral {
+		return 0, &RuntimeError{
+			Token:   operator,
+			Message: fmt.Sprintf("operand must be an integer, got %s", stringifyElement(operand)),
+		}
+	}
+	return iValue, nil
+}
+
+// bitwise evaluate & | ^ << >> on integral numbers, >> is arithmetic shift
+func bitwise(operator token.Token, left, right value.Value) (value.Value, error) {
+	if left.Kind() == value.BigIntKind || right.Kind() == value.BigIntKind {
+		return bigBitwise(operator, left, right)
+	}
+	iLeft, err := integer(operator, left)
+	if err != nil {
+		return value.NilValue, err
+	}
+	iRight, err := integer(operator, right)
+	if err != nil {
+		return value.NilValue, err
+	}
+	switch operator.Type {
+	case token.AMPERSAND:
+		return value.Int(iLeft & iRight), nil
+	case token.PIPE:
+		return value.Int(iLeft | iRight), nil
+	case token.CARET:
+		return value.Int(iLeft ^ iRight), nil
+	}
+	if iRight < 0 || iRight > 63 {
+		return value.NilValue, &RuntimeError{
+			Token:   operator,
+			Message: fmt.Sprintf("shift count must be in [0, 63], got %d", iRight),
+		}
 	}
-	return true
+	if operator.Type == token.LESSLESS {
+		return value.Int(iLeft << iRight), nil
+	}
+	return value.Int(iLeft >> iRight), nil
 }
 
-func isEqual(left, right interface{}) bool {
-	return left == right
+// compoundOperator binary operator of compound assign or increment, keep location of the original
+func compoundOperator(operator token.Token) token.Token {
+	res := operator
+	switch operator.Type {
+	case token.PLUSEQUAL, token.PLUSPLUS:
+		res.Type = token.PLUS
+	case token.MINUSEQUAL, token.MINUSMINUS:
+		res.Type = token.MINUS
+	case token.STAREQUAL:
+		res.Type = token.STAR
+	case token.SLASHEQUAL:
+		res.Type = token.SLASH
+	case token.PERCENTEQUAL:
+		res.Type = token.PERCENT
+	}
+	return res
 }
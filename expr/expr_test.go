@@ -0,0 +1,547 @@
+package expr_test
+
+import (
+	"errors"
+	"fmt"
+	"learning/glox/expr"
+	"learning/glox/parser"
+	"learning/glox/scanner"
+	"learning/glox/token"
+	"learning/glox/value"
+	"strings"
+	"testing"
+)
+
+func evaluate(t *testing.T, rt *expr.Runtime, source string) (value.Value, error) {
+	t.Helper()
+	tokens, err := scanner.ScanLine(source)
+	if err != nil {
+		t.Fatalf("scan %q: %v", source, err)
+	}
+	e, err := parser.ParseLine(tokens)
+	if err != nil {
+		t.Fatalf("parse %q: %v", source, err)
+	}
+	return e.Evaluate(rt)
+}
+
+// evalTest source evaluates to want, printed like the REPL, or fails with err in its message
+type evalTest struct {
+	source string
+	want   string
+	err    string
+}
+
+func checkEval(t *testing.T, tests []evalTest) {
+	t.Helper()
+	for _, test := range tests {
+		got, err := evaluate(t, nil, test.source)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: error = %v, want %q", test.source, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		if value.Stringify(got) != test.want {
+			t.Errorf("%s = %s, want %s", test.source, value.Stringify(got), test.want)
+		}
+	}
+}
+
+// TestCompareMixed int and float are compared exactly, like ==
+func TestCompareMixed(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		// 2**53 + 1 is not a float, converted it would equal 2**53
+		{"9007199254740993 > 9007199254740992.0", true},
+		{"9007199254740993 <= 9007199254740992.0", false},
+		{"9007199254740992.0 < 9007199254740993", true},
+		{"9007199254740993 == 9007199254740992.0", false},
+		{"9223372036854775807 < 9223372036854775808.0", true},
+		{"9223372036854775807 >= 9223372036854775808.0", false},
+		{"1 < 1.5", true},
+		{"2 >= 2.0", true},
+		{"-1 > -1.5", true},
+		{"1 < 1 / 0", true},
+		{"1 > -1 / 0", true},
+		// NaN is not ordered
+		{"1 < 0.0 / 0", false},
+		{"1 >= 0.0 / 0", false},
+	}
+	for _, test := range tests {
+		got, err := evaluate(t, nil, test.source)
+		if err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		if !value.Equals(got, value.Bool(test.want)) {
+			t.Errorf("%s = %s, want %t", test.source, value.Stringify(got), test.want)
+		}
+	}
+}
+
+// TestBigPowerTooLarge power fails before it is computed, even without runtime,
+// like when optimizer folds it, powers of 0, 1 and -1 are small
+func TestBigPowerTooLarge(t *testing.T) {
+	for _, source := range []string{"2n ** 100000000000n", "3 ** 100000000000n", "0.5d ** 100000000000n"} {
+		if _, err := evaluate(t, nil, source); err == nil {
+			t.Errorf("%s: want error", source)
+		}
+	}
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"1n ** 100000000000n", "1"},
+		{"(-1n) ** 100000000001n", "-1"},
+		{"0n ** 100000000000n", "0"},
+		{"1d ** -100000000000n", "1.0"},
+		{"2n ** 64", "18446744073709551616"},
+	}
+	for _, test := range tests {
+		got, err := evaluate(t, nil, test.source)
+		if err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		if value.Stringify(got) != test.want {
+			t.Errorf("%s = %s, want %s", test.source, value.Stringify(got), test.want)
+		}
+	}
+}
+
+// TestLoxConformance expected outputs of the official crafting interpreters test suite,
+// test/number, test/operator and test/precedence.lox, without print. glox differs where
+// it has ints: integral float results keep ".0", and int -0 is 0
+func TestLoxConformance(t *testing.T) {
+	tests := []struct {
+		source string
+		jlox   string // expected output of the suite
+		want   string
+	}{
+		// number/literals.lox
+		{"123", "123", "123"},
+		{"987654", "987654", "987654"},
+		{"0", "0", "0"},
+		{"-0", "-0", "0"}, // int negation, -0.0 is "-0.0"
+		{"123.456", "123.456", "123.456"},
+		{"-0.001", "-0.001", "-0.001"},
+		// number/nan_equality.lox
+		{"0 / 0 == 0", "false", "false"},
+		{"0 / 0 != 0", "true", "true"},
+		{"0 / 0 == 0 / 0", "false", "false"},
+		{"0 / 0 != 0 / 0", "true", "true"},
+		// operator/add.lox, subtract.lox, multiply.lox, divide.lox, negate.lox
+		{"123 + 456", "579", "579"},
+		{`"str" + "ing"`, "string", "string"},
+		{"4 - 3", "1", "1"},
+		{"1.2 - 1.2", "0", "0.0"}, // float result
+		{"5 * 3", "15", "15"},
+		{"12.34 * 0.3", "3.702", "3.702"},
+		{"8 / 2", "4", "4.0"},         // `/` is float division
+		{"12.34 / 12.34", "1", "1.0"}, // float result
+		{"-(3)", "-3", "-3"},
+		// operator/comparison.lox, equals.lox, not_equals.lox
+		{"1 < 2", "true", "true"},
+		{"2 <= 2", "true", "true"},
+		{"2 > 2", "false", "false"},
+		{"0 < -0", "false", "false"},
+		{"-0 >= 0", "true", "true"},
+		{"nil == nil", "true", "true"},
+		{"1 == 2", "false", "false"},
+		{`"str" == "str"`, "true", "true"},
+		{"nil == false", "false", "false"},
+		{"false == 0", "false", "false"},
+		{`0 == "0"`, "false", "false"},
+		{`"str" != "ing"`, "true", "true"},
+		// precedence.lox
+		{"2 + 3 * 4", "14", "14"},
+		{"20 - 3 * 4", "8", "8"},
+		{"2 + 6 / 3", "4", "4.0"}, // `/` is float division
+		{"2 - 6 / 3", "0", "0.0"}, // `/` is float division
+		{"false == 2 < 1", "true", "true"},
+		{"false == 1 > 2", "true", "true"},
+		{"1 - 1", "0", "0"},
+		{"1 -1", "0", "0"},
+		{"1- 1", "0", "0"},
+		{"1-1", "0", "0"},
+		{"(2 * (6 - (2 + 2)))", "4", "4"},
+	}
+	for _, test := range tests {
+		got, err := evaluate(t, nil, test.source)
+		if err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		if value.Stringify(got) != test.want {
+			t.Errorf("%s = %s, want %s, jlox prints %s", test.source, value.Stringify(got), test.want, test.jlox)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: "[1, 2, 3][0]", want: "1"},
+		{source: "[[1, 2], [3]][0][1]", want: "2"},
+		{source: "[1, 2, 3][1.0]", want: "2"},
+		{source: "[1, 2, 3][1n]", want: "2"},
+		{source: `str([1, "a", nil, [1.5]])`, want: `[1, "a", nil, [1.5]]`},
+		// negative index count from the end
+		{source: "[1, 2, 3][-1]", want: "3"},
+		{source: "[1, 2, 3][-3]", want: "1"},
+		{source: "[1, 2, 3][3]", err: "list index 3 out of range for length 3"},
+		{source: "[1, 2, 3][-4]", err: "list index -4 out of range for length 3"},
+		{source: "[][0]", err: "list index 0 out of range for length 0"},
+		{source: "[1][0.5]", err: "list index must be an integer, got 0.5"},
+		{source: `[1]["0"]`, err: `list index must be an integer, got "0"`},
+		{source: "[1][nil]", err: "list index must be an integer, got nil"},
+		{source: "1[0]", err: "only lists and maps can be indexed, got int"},
+	})
+}
+
+func TestListSlice(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: "[1, 2, 3][1:]", want: "[2, 3]"},
+		{source: "[1, 2, 3][:2]", want: "[1, 2]"},
+		{source: "[1, 2, 3][:]", want: "[1, 2, 3]"},
+		{source: "[1, 2, 3][1:2]", want: "[2]"},
+		{source: "[1, 2, 3][-2:]", want: "[2, 3]"},
+		{source: "[1, 2, 3][:-1]", want: "[1, 2]"},
+		// bounds are clamped
+		{source: "[1, 2, 3][-10:10]", want: "[1, 2, 3]"},
+		{source: "[1, 2, 3][5:]", want: "[]"},
+		{source: "[1, 2, 3][2:1]", want: "[]"},
+		{source: "[][:]", want: "[]"},
+		{source: "[1, 2, 3][1.5:]", err: "slice bound must be an integer, got 1.5"},
+		{source: `[1, 2, 3][:"1"]`, err: `slice bound must be an integer, got "1"`},
+		{source: `"ab"[0:1]`, err: "only lists can be sliced, got string"},
+	})
+}
+
+func TestListNatives(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: "len([])", want: "0"},
+		{source: "len([1, [2, 3]])", want: "2"},
+		{source: `len("héllo")`, want: "5"},
+		{source: "append([1], 2)", want: "[1, 2]"},
+		{source: "append([], [1])", want: "[[1]]"},
+		{source: "pop([1, 2])", want: "2"},
+		{source: "pop([])", err: "pop from empty list"},
+		{source: "len(1)", err: "len() argument must be a list, map or string, got int"},
+		{source: "append(1, 2)", err: "append() argument 1 must be a list, got int"},
+		{source: "pop({})", err: "pop() argument must be a list, got map"},
+	})
+}
+
+func TestListEquals(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: "[1, 2] == [1, 2]", want: "true"},
+		{source: "[1, 2] == [1.0, 2n]", want: "true"},
+		{source: "[1, 2] == [2, 1]", want: "false"},
+		{source: "[1] == [1, 1]", want: "false"},
+		{source: "[] == []", want: "true"},
+		{source: "[[1]] == [[1]]", want: "true"},
+		{source: "[1] == 1", want: "false"},
+		{source: `["a"] != ["b"]`, want: "true"},
+		{source: "[0 / 0] == [0 / 0]", want: "false"},
+	})
+}
+
+// TestListIndexError error is at the bracket of the index, on its line
+func TestListIndexError(t *testing.T) {
+	_, err := evaluate(t, nil, "\n\n[1, 2][5]")
+	var runtimeError *expr.RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("error = %v, want runtime error", err)
+	}
+	if runtimeError.Token.Type != token.LEFTBRACKET || runtimeError.Token.Line != 3 {
+		t.Errorf("error token = %v, want '[' on line 3", runtimeError.Token)
+	}
+	want := "[line 3] Error at '[': list index 5 out of range for length 2"
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}
+
+// TestMapNumberKeys numbers of every kind are the same key when they are equal
+func TestMapNumberKeys(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: `{1: "a"}[1.0]`, want: "a"},
+		{source: `{1: "a"}[1n]`, want: "a"},
+		{source: `{1: "a"}[1d]`, want: "a"},
+		{source: `{1.0: "a"}[1]`, want: "a"},
+		{source: `{0: "a"}[-0.0]`, want: "a"},
+		{source: `{0.5: "a"}[1d / 2d]`, want: "a"},
+		{source: `{1d / 3d: "a"}[1d / 3d]`, want: "a"},
+		{source: `{2n ** 70: "a"}[2.0 ** 70]`, want: "a"},
+		{source: `len({1: "a", 1.0: "b", 1n: "c", 1d: "d"})`, want: "1"},
+		// the first key is kept, the value is the last one
+		{source: `str({1.0: "a", 1: "b"})`, want: `{1.0: "b"}`},
+		{source: `keys({1n: "a", 1: "b"})`, want: "[1]"},
+		{source: `{1.5: "a"}[1]`, err: "undefined key 1"},
+	})
+}
+
+func TestMapKeyErrors(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: `{"a": 1}["b"]`, err: `undefined key "b"`},
+		{source: "{0 / 0: 1}", err: "map key can not be NaN"},
+		{source: "{}[0 / 0]", err: "map key can not be NaN"},
+		{source: "0 / 0 in {}", err: "map key can not be NaN"},
+		{source: "{[1]: 1}", err: "map key must be a string or number, got list"},
+		{source: "{nil: 1}", err: "map key must be a string or number, got nil"},
+		{source: "{}[true]", err: "map key must be a string or number, got bool"},
+	})
+}
+
+func TestIn(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: `"a" in {"a": 1}`, want: "true"},
+		{source: `"b" in {"a": 1}`, want: "false"},
+		{source: "1.0 in {1: 0}", want: "true"},
+		{source: "2 in [1, 2]", want: "true"},
+		{source: "2.0 in [1, 2]", want: "true"},
+		{source: `"2" in [1, 2]`, want: "false"},
+		{source: "[1] in [[1]]", want: "true"},
+		{source: "0 / 0 in [0 / 0]", want: "false"},
+		{source: "1 in 1", err: "right operand of 'in' must be a map or list, got int"},
+	})
+}
+
+func TestMapEquals(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: `{"a": 1, "b": 2} == {"b": 2, "a": 1}`, want: "true"},
+		{source: `{1: "a"} == {1.0: "a"}`, want: "true"},
+		{source: `{"a": 1} == {"a": 1.0}`, want: "true"},
+		{source: `{"a": 1} == {"a": 2}`, want: "false"},
+		{source: `{"a": 1} == {"a": 1, "b": 2}`, want: "false"},
+		{source: `{"a": 1} == {"b": 1}`, want: "false"},
+		{source: "{} == {}", want: "true"},
+		{source: "{} == []", want: "false"},
+	})
+}
+
+// TestMapOrder keys keep insertion order, deleted and inserted again it is last,
+// updated it stays in place
+func TestMapOrder(t *testing.T) {
+	tests := []evalTest{
+		{source: `keys({"b": 1, "a": 2, 3: 3})`, want: `["b", "a", 3]`},
+		{source: `m["b"] = 4, keys(m)`, want: `["a", "b", "c"]`},
+		{source: `delete(m, "a"), m["a"] = 4, keys(m)`, want: `["b", "c", "a"]`},
+		{source: `delete(m, "b"), str(m)`, want: `{"a": 1, "c": 3}`},
+		{source: `m[1] = 1, m["d"] = 1, keys(m)`, want: `["a", "b", "c", 1, "d"]`},
+		{source: `delete(m, "a")`, want: "true"},
+		{source: `delete(m, "x")`, want: "false"},
+		{source: `delete(m, [1])`, err: "map key must be a string or number, got list"},
+	}
+	for _, test := range tests {
+		defineGlobal(t, "m", `{"a": 1, "b": 2, "c": 3}`)
+		checkEval(t, []evalTest{test})
+	}
+}
+
+// defineGlobal define global name as value of source, removed when the test ends
+func defineGlobal(t *testing.T, name, source string) {
+	t.Helper()
+	v, err := evaluate(t, nil, source)
+	if err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	expr.Globals[name] = v
+	t.Cleanup(func() {
+		delete(expr.Globals, name)
+	})
+}
+
+// TestAssignOnce receiver and index of a target are evaluated exactly once
+func TestAssignOnce(t *testing.T) {
+	tests := []evalTest{
+		{source: "xs[pop(idx)] += 5, str([xs, idx])", want: "[[10, 25, 30], [0]]"},
+		{source: "xs[pop(idx)]++, str([xs, idx])", want: "[[10, 21, 30], [0]]"},
+		{source: "--xs[pop(idx)], str([xs, idx])", want: "[[10, 19, 30], [0]]"},
+		{source: "xs[pop(idx)] = 1, str([xs, idx])", want: "[[10, 1, 30], [0]]"},
+		{source: "pop([xs, xs])[pop(idx)] *= 2, str([xs, idx])", want: "[[10, 40, 30], [0]]"},
+	}
+	for _, test := range tests {
+		defineGlobal(t, "xs", "[10, 20, 30]")
+		defineGlobal(t, "idx", "[0, 1]")
+		checkEval(t, []evalTest{test})
+	}
+}
+
+func TestCompoundAssign(t *testing.T) {
+	tests := []evalTest{
+		{source: "xs[0] += 1", want: "11"},
+		{source: "xs[1] -= 1, xs[1]", want: "19"},
+		{source: "xs[2] /= 4", want: "7.5"},
+		{source: "xs[2] %= 7", want: "2"},
+		{source: "xs[0]++", want: "10"},
+		{source: "xs[0]++, xs[0]", want: "11"},
+		{source: "++xs[0]", want: "11"},
+		{source: "xs[-1]--, str(xs)", want: "[10, 20, 29]"},
+		{source: `m["a"] += 1, m["a"]`, want: "2"},
+		{source: `m["b"] = 1, str(m)`, want: `{"a": 1, "b": 1}`},
+		{source: `m["b"] += 1`, err: `undefined key "b"`},
+		{source: "xs[3] = 1", err: "list index 3 out of range for length 3"},
+		{source: "xs[0] += nil", err: "Error at '+=': operands must be numbers, got int and nil"},
+	}
+	for _, test := range tests {
+		defineGlobal(t, "xs", "[10, 20, 30]")
+		defineGlobal(t, "m", `{"a": 1}`)
+		checkEval(t, []evalTest{test})
+	}
+}
+
+// TestIncrementNotNumber ++ and -- leave non numbers unchanged and report an error
+func TestIncrementNotNumber(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: `["a"][0]++`, err: "operand of '++' must be a number, got string"},
+		{source: `++["a"][0]`, err: "operand of '++' must be a number, got string"},
+		{source: "--[nil][0]", err: "operand of '--' must be a number, got nil"},
+		{source: "[true][0]--", err: "operand of '--' must be a number, got bool"},
+		{source: `{"k": [1]}["k"]++`, err: "operand of '++' must be a number, got list"},
+		{source: "[1n][0]++", want: "1"},
+		{source: "++[1d][0]", want: "2.0"},
+		{source: "--[0.5][0]", want: "-0.5"},
+	})
+	defineGlobal(t, "xs", `["a"]`)
+	checkEval(t, []evalTest{
+		{source: "xs[0]++", err: "must be a number"},
+		{source: "str(xs)", want: `["a"]`},
+	})
+}
+
+func TestFreeze(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: "freeze([1])[0] = 2", err: "Error at '[': can not modify frozen list"},
+		{source: "freeze([1])[0] += 2", err: "Error at '[': can not modify frozen list"},
+		{source: "freeze([1])[0]++", err: "Error at '[': can not modify frozen list"},
+		{source: "append(freeze([]), 1)", err: "Error at ')': can not modify frozen list"},
+		{source: "pop(freeze([1]))", err: "Error at ')': can not modify frozen list"},
+		{source: `freeze({})["a"] = 1`, err: "Error at '[': can not modify frozen map"},
+		{source: "freeze({1: 2})[1] = 3", err: "Error at '[': can not modify frozen map"},
+		{source: "delete(freeze({1: 2}), 1)", err: "Error at ')': can not modify frozen map"},
+		// reading is allowed, freeze is shallow
+		{source: "freeze([1, 2])[-1]", want: "2"},
+		{source: "len(freeze({1: 2}))", want: "1"},
+		{source: "freeze([[1]])[0][0] = 2", want: "2"},
+		{source: "append(freeze([[]])[0], 1)", want: "[1]"},
+		{source: "freeze([1]) == [1]", want: "true"},
+		{source: "freeze(1)", err: "freeze() argument must be a list or map, got int"},
+	})
+	defineGlobal(t, "xs", "[1, 2]")
+	checkEval(t, []evalTest{
+		{source: "freeze(xs), pop(xs)", err: "can not modify frozen list"},
+		{source: "str(xs)", want: "[1, 2]"},
+	})
+}
+
+func TestNativeTypeCheck(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: "pop(1)", err: "Error at ')': pop() argument must be a list, got int"},
+		{source: "append(1, 2)", err: "append() argument 1 must be a list, got int"},
+		{source: "delete([], 1)", err: "delete() argument 1 must be a map, got list"},
+		{source: "exit(1.0)", err: "exit() argument must be an int, got float"},
+		{source: "exit(1n)", err: "exit() argument must be an int, got bigint"},
+		{source: "len(1)", err: "len() argument must be a list, map or string, got int"},
+		{source: "freeze(nil)", err: "freeze() argument must be a list or map, got nil"},
+		{source: "arity(1)", err: "arity() argument must be a function, got int"},
+		{source: "num(nil)", err: "num() argument must be a string or number, got nil"},
+		{source: `int("1")`, err: "int() argument must be a number, got string"},
+		{source: "pop()", err: "expected 1 arguments but got 0"},
+	})
+}
+
+func TestNum(t *testing.T) {
+	checkEval(t, []evalTest{
+		{source: `num("42")`, want: "42"},
+		{source: `type(num("42"))`, want: "int"},
+		{source: `num(" -7 ")`, want: "-7"},
+		{source: `num("+5")`, want: "5"},
+		{source: `num("1.5")`, want: "1.5"},
+		{source: `num("1e3")`, want: "1000.0"},
+		{source: `type(num("1.0"))`, want: "float"},
+		{source: `num("9223372036854775808")`, want: "9.223372036854776E18"},
+		{source: "num(1n)", want: "1"},
+		{source: "num(1.5)", want: "1.5"},
+		{source: `num("")`, err: `num() can not convert "" to number`},
+		{source: `num("1_000")`, err: `num() can not convert "1_000" to number`},
+		{source: `num("12abc")`, err: `num() can not convert "12abc" to number`},
+		{source: `num("1n")`, err: `num() can not convert "1n" to number`},
+		{source: `num("inf")`, err: `num() can not convert "inf" to number`},
+		{source: `num("NaN")`, err: `num() can not convert "NaN" to number`},
+		{source: `num("0x1p4")`, err: `num() can not convert "0x1p4" to number`},
+		{source: `num("1e")`, err: `num() can not convert "1e" to number`},
+		{source: `num("1e999")`, err: `num() can not convert "1e999" to number`},
+	})
+}
+
+func TestExit(t *testing.T) {
+	for _, code := range []int{0, 3, -1, 256} {
+		source := fmt.Sprintf("exit(%d)", code)
+		_, err := evaluate(t, nil, source)
+		var exit *expr.ExitError
+		if !errors.As(err, &exit) || exit.Code != code {
+			t.Errorf("%s: error = %v, want exit with code %d", source, err, code)
+		}
+	}
+	// exit stops evaluating the rest of the expression
+	defineGlobal(t, "xs", "[]")
+	_, err := evaluate(t, nil, "exit(2), append(xs, 1)")
+	var exit *expr.ExitError
+	if !errors.As(err, &exit) || exit.Code != 2 {
+		t.Errorf("error = %v, want exit with code 2", err)
+	}
+	checkEval(t, []evalTest{{source: "len(xs)", want: "0"}})
+}
+
+func TestDefineNativeInvalid(t *testing.T) {
+	noop := func(rt *expr.Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+		return value.NilValue, nil
+	}
+	tests := []struct {
+		native *expr.NativeFunction
+		want   string
+	}{
+		{
+			native: &expr.NativeFunction{Name: "f", ArgCount: 1, Params: [][]string{{"int"}, {"int"}}, Function: noop},
+			want:   "native f() has 2 params but 1 arguments",
+		},
+		{
+			native: &expr.NativeFunction{Name: "f", ArgCount: 1, Params: [][]string{{}}, Function: noop},
+			want:   "native f() param 1 accepts no type",
+		},
+		{
+			native: &expr.NativeFunction{Name: "f", ArgCount: 2, Params: [][]string{{"int"}, {"int", ""}}, Function: noop},
+			want:   "native f() param 2 has an empty type name",
+		},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if got := recover(); got != test.want {
+					t.Errorf("panic = %v, want %q", got, test.want)
+				}
+			}()
+			expr.DefineNative(test.native)
+		}()
+		if _, ok := expr.Globals["f"]; ok {
+			t.Errorf("invalid native %v is defined", test.native)
+		}
+	}
+	// fewer params than arguments leave the rest unchecked
+	expr.DefineNative(&expr.NativeFunction{Name: "f", ArgCount: 2, Params: [][]string{{"int"}}, Function: noop})
+	t.Cleanup(func() {
+		delete(expr.Globals, "f")
+	})
+	checkEval(t, []evalTest{
+		{source: `f(1, "a")`, want: "nil"},
+		{source: `f("a", 1)`, err: "f() argument must be an int, got string"},
+	})
+}
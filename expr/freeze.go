@@ -0,0 +1,37 @@
+package expr
+
+import (
+	"learning/glox/token"
+	"learning/glox/value"
+)
+
+// checkMutable report error at location when object is frozen
+func checkMutable(location token.Token, object value.Object) error {
+	frozen := false
+	name := ""
+	switch v := object.(type) {
+	case *LoxList:
+		frozen, name = v.frozen, "list"
+	case *LoxMap:
+		frozen, name = v.frozen, "map"
+	}
+	if !frozen {
+		return nil
+	}
+	return &RuntimeError{
+		Token:   location,
+		Message: "can not modify frozen " + name,
+	}
+}
+
+// nativeFreeze make list or map read only, shallow, elements are not frozen,
+// return the same object
+func nativeFreeze(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	if list, ok := asList(arguments[0]); ok {
+		list.frozen = true
+		return arguments[0], nil
+	}
+	m, _ := asMap(arguments[0])
+	m.frozen = true
+	return arguments[0], nil
+}
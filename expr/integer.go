@@ -0,0 +1,140 @@
+package expr
+
+import (
+	"learning/glox/token"
+	"learning/glox/value"
+	"math"
+	"math/big"
+)
+
+// intOperation apply arithmetic or comparison operator to two ints,
+// result stays int except `/`, which is float division like before,
+// and `**` with negative exponent
+func intOperation(operator token.Token, left, right int64) (value.Value, error) {
+	switch operator.Type {
+	case token.GREATER:
+		return value.Bool(left > right), nil
+	case token.GREATEREQUAL:
+		return value.Bool(left >= right), nil
+	case token.LESS:
+		return value.Bool(left < right), nil
+	case token.LESSEQUAL:
+		return value.Bool(left <= right), nil
+	case token.PLUS:
+		res := left + right
+		// overflow when both operands have the same sign, and result has the other
+		if (left >= 0) == (right >= 0) && (res >= 0) != (left >= 0) {
+			return value.NilValue, overflow(operator)
+		}
+		return value.Int(res), nil
+	case token.MINUS:
+		res := left - right
+		if (left >= 0) != (right >= 0) && (res >= 0) != (left >= 0) {
+			return value.NilValue, overflow(operator)
+		}
+		return value.Int(res), nil
+	case token.STAR:
+		res, ok := multiply(left, right)
+		if !ok {
+			return value.NilValue, overflow(operator)
+		}
+		return value.Int(res), nil
+	case token.SLASH:
+		return value.Number(float64(left) / float64(right)), nil
+	case token.PERCENT, token.TILDESLASH:
+		if right == 0 {
+			return value.NilValue, &RuntimeError{
+				Token:   operator,
+				Message: "integer division by zero",
+			}
+		}
+		if operator.Type == token.PERCENT {
+			// like go, result has the sign of left
+			return value.Int(left % right), nil
+		}
+		if left == math.MinInt64 && right == -1 {
+			return value.NilValue, overflow(operator)
+		}
+		// truncate toward zero
+		return value.Int(left / right), nil
+	case token.STARSTAR:
+		if right < 0 {
+			return value.Number(math.Pow(float64(left), float64(right))), nil
+		}
+		res, ok := power(left, right)
+		if !ok {
+			return value.NilValue, overflow(operator)
+		}
+		return value.Int(res), nil
+	}
+	return value.NilValue, nil
+}
+
+// multiply int64 multiplication, ok is false on overflow
+func multiply(left, right int64) (int64, bool) {
+	if left == 0 || right == 0 {
+		return 0, true
+	}
+	res := left * right
+	if res/right != left || (left == -1 && right == math.MinInt64) ||
+		(right == -1 && left == math.MinInt64) {
+		return 0, false
+	}
+	return res, true
+}
+
+// power exponentiation by squaring, exponent is not negative, ok is false on overflow
+func power(base, exponent int64) (int64, bool) {
+	res := int64(1)
+	ok := true
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			res, ok = multiply(res, base)
+			if !ok {
+				return 0, false
+			}
+		}
+		exponent >>= 1
+		if exponent > 0 {
+			base, ok = multiply(base, base)
+			if !ok {
+				return 0, false
+			}
+		}
+	}
+	return res, true
+}
+
+func overflow(operator token.Token) *RuntimeError {
+	return &RuntimeError{
+		Token:   operator,
+		Message: "integer overflow",
+	}
+}
+
+func nativeInt(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	if _, ok := arguments[0].Int(); ok {
+		return arguments[0], nil
+	}
+	fValue, _ := arguments[0].Number()
+	// truncate toward zero
+	iValue, ok := value.IntOf(math.Trunc(fValue))
+	if arguments[0].IsBig() {
+		// exactly, float conversion would lose digits
+		rValue, _ := arguments[0].Rat()
+		truncated := new(big.Int).Quo(rValue.Num(), rValue.Denom())
+		iValue, ok = truncated.Int64(), truncated.IsInt64()
+	}
+	if !ok {
+		return value.NilValue, &RuntimeError{
+			Token:   paren,
+			Message: "int() argument out of range, got " + value.Stringify(arguments[0]),
+		}
+	}
+	return value.Int(iValue), nil
+}
+
+func nativeFloat(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	fValue, _ := arguments[0].Number()
+	return value.Number(fValue), nil
+}
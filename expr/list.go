@@ -0,0 +1,143 @@
+package expr
+
+import (
+	"fmt"
+	"learning/glox/token"
+	"learning/glox/value"
+	"math"
+	"strings"
+)
+
+// LoxList runtime list value
+type LoxList struct {
+	Elements []value.Value
+	frozen   bool // frozen list can not be modified, set by freeze()
+}
+
+func (l *LoxList) String() string {
+	items := []string{}
+	for _, element := range l.Elements {
+		items = append(items, stringifyElement(element))
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// TypeName type name of list
+func (l *LoxList) TypeName() string {
+	return "list"
+}
+
+// Equals lists are equal when their elements are equal
+func (l *LoxList) Equals(other value.Object) bool {
+	list, ok := other.(*LoxList)
+	if !ok || len(l.Elements) != len(list.Elements) {
+		return false
+	}
+	for i := range l.Elements {
+		if !value.Equals(l.Elements[i], list.Elements[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// stringifyElement print element of list or map, strings are quoted
+func stringifyElement(element value.Value) string {
+	if str, ok := element.Str(); ok {
+		return "\"" + str + "\""
+	}
+	return value.Stringify(element)
+}
+
+// asList get list object of value
+func asList(v value.Value) (*LoxList, bool) {
+	object, _ := v.Object()
+	list, ok := object.(*LoxList)
+	return list, ok
+}
+
+// index check value is a valid index of list, negative index count from the end
+func (l *LoxList) index(bracket token.Token, v value.Value) (int, error) {
+	fIndex, ok := v.Number()
+	if !ok || fIndex != math.Trunc(fIndex) {
+		return 0, &RuntimeError{
+			Token:   bracket,
+			Message: fmt.Sprintf("list index must be an integer, got %s", stringifyElement(v)),
+		}
+	}
+	index := fIndex
+	if index < 0 {
+		index += float64(len(l.Elements))
+	}
+	if index < 0 || index >= float64(len(l.Elements)) {
+		return 0, &RuntimeError{
+			Token: bracket,
+			Message: fmt.Sprintf(
+				"list index %v out of range for length %d", fIndex, len(l.Elements)),
+		}
+	}
+	return int(index), nil
+}
+
+// bound evaluate slice bound, clamped to [0, len], nil means missing
+func (l *LoxList) bound(rt *Runtime, bracket token.Token, e Expr, missing int) (int, error) {
+	v, err := e.Evaluate(rt)
+	if err != nil {
+		return 0, err
+	}
+	if v.IsNil() {
+		return missing, nil
+	}
+	fBound, ok := v.Number()
+	if !ok || fBound != math.Trunc(fBound) {
+		return 0, &RuntimeError{
+			Token:   bracket,
+			Message: fmt.Sprintf("slice bound must be an integer, got %s", stringifyElement(v)),
+		}
+	}
+	size := float64(len(l.Elements))
+	if fBound < 0 {
+		fBound += size
+	}
+	return int(math.Max(0, math.Min(fBound, size))), nil
+}
+
+func nativeLen(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	if str, ok := arguments[0].Str(); ok {
+		return value.Int(int64(len([]rune(str)))), nil
+	}
+	if list, ok := asList(arguments[0]); ok {
+		return value.Int(int64(len(list.Elements))), nil
+	}
+	m, _ := asMap(arguments[0])
+	return value.Int(int64(len(m.keys))), nil
+}
+
+// nativeAppend append value to list in place, return the list
+func nativeAppend(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	list, _ := asList(arguments[0])
+	err := checkMutable(paren, list)
+	if err != nil {
+		return value.NilValue, err
+	}
+	list.Elements = append(list.Elements, arguments[1])
+	return arguments[0], nil
+}
+
+// nativePop remove the last element of list, return it
+func nativePop(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	list, _ := asList(arguments[0])
+	err := checkMutable(paren, list)
+	if err != nil {
+		return value.NilValue, err
+	}
+	if len(list.Elements) == 0 {
+		return value.NilValue, &RuntimeError{
+			Token:   paren,
+			Message: "pop from empty list",
+		}
+	}
+	last := list.Elements[len(list.Elements)-1]
+	list.Elements = list.Elements[:len(list.Elements)-1]
+	return last, nil
+}
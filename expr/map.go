@@ -0,0 +1,228 @@
+package expr
+
+import (
+	"fmt"
+	"learning/glox/token"
+	"learning/glox/value"
+	"math"
+	"strings"
+)
+
+// LoxMap runtime map value, keys keep insertion order
+type LoxMap struct {
+	keys    []interface{}             // hash of keys, in insertion order
+	entries map[interface{}]*mapEntry // hash of key => entry
+	frozen  bool                      // frozen map can not be modified
+}
+
+// mapEntry keep the original key, so keys() return glox values
+type mapEntry struct {
+	key   value.Value
+	value value.Value
+}
+
+// NewLoxMap create empty map
+func NewLoxMap() *LoxMap {
+	return &LoxMap{
+		keys:    []interface{}{},
+		entries: map[interface{}]*mapEntry{},
+	}
+}
+
+// Keys map keys in insertion order
+func (m *LoxMap) Keys() []value.Value {
+	res := []value.Value{}
+	for _, hash := range m.keys {
+		res = append(res, m.entries[hash].key)
+	}
+	return res
+}
+
+func (m *LoxMap) String() string {
+	items := []string{}
+	for _, hash := range m.keys {
+		entry := m.entries[hash]
+		items = append(items, stringifyElement(entry.key)+": "+stringifyElement(entry.value))
+	}
+	return "{" + strings.Join(items, ", ") + "}"
+}
+
+// TypeName type name of map
+func (m *LoxMap) TypeName() string {
+	return "map"
+}
+
+// Equals maps are equal when they have the same keys, with equal values
+func (m *LoxMap) Equals(other value.Object) bool {
+	o, ok := other.(*LoxMap)
+	if !ok || len(m.keys) != len(o.keys) {
+		return false
+	}
+	for hash, entry := range m.entries {
+		oEntry, ok := o.entries[hash]
+		if !ok || !value.Equals(entry.value, oEntry.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// asMap get map object of value
+func asMap(v value.Value) (*LoxMap, bool) {
+	object, _ := v.Object()
+	m, ok := object.(*LoxMap)
+	return m, ok
+}
+
+func (m *LoxMap) get(bracket token.Token, key value.Value) (value.Value, error) {
+	hash, err := hashKey(bracket, key)
+	if err != nil {
+		return value.NilValue, err
+	}
+	entry, ok := m.entries[hash]
+	if !ok {
+		return value.NilValue, &RuntimeError{
+			Token:   bracket,
+			Message: fmt.Sprintf("undefined key %s", stringifyElement(key)),
+		}
+	}
+	return entry.value, nil
+}
+
+func (m *LoxMap) set(bracket token.Token, key, v value.Value) error {
+	err := checkMutable(bracket, m)
+	if err != nil {
+		return err
+	}
+	hash, err := hashKey(bracket, key)
+	if err != nil {
+		return err
+	}
+	entry, ok := m.entries[hash]
+	if ok {
+		entry.value = v
+		return nil
+	}
+	m.keys = append(m.keys, hash)
+	m.entries[hash] = &mapEntry{
+		key:   key,
+		value: v,
+	}
+	return nil
+}
+
+// delete remove key, report whether key was in map
+func (m *LoxMap) delete(paren token.Token, key value.Value) (bool, error) {
+	err := checkMutable(paren, m)
+	if err != nil {
+		return false, err
+	}
+	hash, err := hashKey(paren, key)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := m.entries[hash]; !ok {
+		return false, nil
+	}
+	delete(m.entries, hash)
+	for i, item := range m.keys {
+		if item == hash {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+	return true, nil
+}
+
+// hashKey map glox key to go map key by glox value semantics,
+// strings hash by content, ints and integral floats hash as int64,
+// so 1 and 1.0 are the same key, other floats hash as float64,
+// bigints and decimals hash like them when exactly equal to one, otherwise as bigKey
+func hashKey(location token.Token, key value.Value) (interface{}, error) {
+	if str, ok := key.Str(); ok {
+		return str, nil
+	}
+	if iKey, ok := key.Int(); ok {
+		return iKey, nil
+	}
+	if key.IsBig() {
+		return bigHashKey(key), nil
+	}
+	if number, ok := key.Number(); ok {
+		if iKey, ok := value.IntOf(number); ok {
+			// -0 is 0 too
+			return iKey, nil
+		}
+		if math.IsNaN(number) {
+			return nil, &RuntimeError{
+				Token:   location,
+				Message: "map key can not be NaN",
+			}
+		}
+		return number, nil
+	}
+	return nil, &RuntimeError{
+		Token:   location,
+		Message: "map key must be a string or number, got " + key.TypeName(),
+	}
+}
+
+// bigKey go map key of bigint or decimal out of int64 and float64,
+// a distinct type, so it never collides with string keys
+type bigKey string
+
+func bigHashKey(key value.Value) interface{} {
+	rKey, _ := key.Rat()
+	if rKey.IsInt() && rKey.Num().IsInt64() {
+		return rKey.Num().Int64()
+	}
+	if fKey, exact := rKey.Float64(); exact {
+		return fKey
+	}
+	if rKey.IsInt() {
+		return bigKey(rKey.Num().String())
+	}
+	return bigKey(rKey.String())
+}
+
+// contains implement `in` operator, key in map, or element in list
+func contains(operator token.Token, element, container value.Value) (value.Value, error) {
+	if m, ok := asMap(container); ok {
+		hash, err := hashKey(operator, element)
+		if err != nil {
+			return value.NilValue, err
+		}
+		_, ok := m.entries[hash]
+		return value.Bool(ok), nil
+	}
+	if list, ok := asList(container); ok {
+		for _, item := range list.Elements {
+			if value.Equals(element, item) {
+				return value.TrueValue, nil
+			}
+		}
+		return value.FalseValue, nil
+	}
+	return value.NilValue, &RuntimeError{
+		Token:   operator,
+		Message: "right operand of 'in' must be a map or list, got " + container.TypeName(),
+	}
+}
+
+// nativeKeys list of map keys, in insertion order
+func nativeKeys(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	m, _ := asMap(arguments[0])
+	return value.FromObject(&LoxList{
+		Elements: m.Keys(),
+	}), nil
+}
+
+// nativeDelete remove key from map, return whether it was there
+func nativeDelete(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	m, _ := asMap(arguments[0])
+	deleted, err := m.delete(paren, arguments[1])
+	if err != nil {
+		return value.NilValue, err
+	}
+	return value.Bool(deleted), nil
+}
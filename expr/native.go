@@ -0,0 +1,140 @@
+package expr
+
+import (
+	"fmt"
+	"learning/glox/token"
+	"learning/glox/value"
+	"strings"
+)
+
+// Callable value can be called by call expr, rt is the runtime of the call, maybe nil
+type Callable interface {
+	Arity() int
+	Call(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error)
+}
+
+// AnyType param type which accepts every value
+const AnyType = "any"
+
+// NumberType param type which accepts int, float, bigint and decimal
+const NumberType = "number"
+
+// NativeFunction function implemented by go
+type NativeFunction struct {
+	Name     string // function name
+	ArgCount int    // number of arguments
+	// Params accepted type names of each argument, like {"list", "map"}, checked before
+	// Function is called, type names are the ones of TypeName(), NumberType or AnyType
+	Params   [][]string
+	Function func(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error)
+}
+
+// Arity number of arguments
+func (n *NativeFunction) Arity() int {
+	return n.ArgCount
+}
+
+// Call check argument types, then call go function
+func (n *NativeFunction) Call(rt *Runtime, paren token.Token, arguments []value.Value) (value.Value, error) {
+	for i, types := range n.Params {
+		if accepts(types, arguments[i]) {
+			continue
+		}
+		position := "argument"
+		if len(n.Params) > 1 {
+			position = fmt.Sprintf("argument %d", i+1)
+		}
+		return value.NilValue, &RuntimeError{
+			Token: paren,
+			Message: fmt.Sprintf("%s() %s must be %s, got %s",
+				n.Name, position, describeTypes(types), arguments[i].TypeName()),
+		}
+	}
+	return n.Function(rt, paren, arguments)
+}
+
+func (n *NativeFunction) String() string {
+	return "<native fn " + n.Name + ">"
+}
+
+// TypeName type name of native function
+func (n *NativeFunction) TypeName() string {
+	return "function"
+}
+
+func accepts(types []string, argument value.Value) bool {
+	for _, name := range types {
+		if name == AnyType || name == argument.TypeName() ||
+			(name == NumberType && argument.IsNumber()) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeTypes like "a list, map or string"
+func describeTypes(types []string) string {
+	article := "a "
+	if strings.ContainsAny(types[0][:1], "aeiou") {
+		article = "an "
+	}
+	if len(types) == 1 {
+		return article + types[0]
+	}
+	return article + strings.Join(types[:len(types)-1], ", ") + " or " + types[len(types)-1]
+}
+
+// DefineNative add native function to globals, or replace the one of the same name,
+// cmd/interpreter and embedders register their own natives with it before evaluating.
+// It writes the shared Globals map, so it must not be called while any expr is evaluated,
+// like during Interpreter.Run, evaluating is safe for concurrent use otherwise.
+// It panics when Params does not fit ArgCount, like flag does on a redefined flag,
+// since Call would index past the arguments
+func DefineNative(native *NativeFunction) {
+	if len(native.Params) > native.ArgCount {
+		panic(fmt.Sprintf("native %s() has %d params but %d arguments",
+			native.Name, len(native.Params), native.ArgCount))
+	}
+	for i, types := range native.Params {
+		if len(types) == 0 {
+			panic(fmt.Sprintf("native %s() param %d accepts no type", native.Name, i+1))
+		}
+		for _, name := range types {
+			if name == "" {
+				panic(fmt.Sprintf("native %s() param %d has an empty type name", native.Name, i+1))
+			}
+		}
+	}
+	Globals[native.Name] = value.FromObject(native)
+}
+
+var (
+	// Globals global variables, only native functions for now
+	Globals = map[string]value.Value{}
+
+	builtins = []*NativeFunction{
+		{Name: "clock", ArgCount: 0, Function: nativeClock},
+		{Name: "str", ArgCount: 1, Params: [][]string{{AnyType}}, Function: nativeStr},
+		{Name: "num", ArgCount: 1, Params: [][]string{{"string", NumberType}}, Function: nativeNum},
+		{Name: "type", ArgCount: 1, Params: [][]string{{AnyType}}, Function: nativeType},
+		{Name: "arity", ArgCount: 1, Params: [][]string{{"function"}}, Function: nativeArity},
+		{Name: "input", ArgCount: 0, Function: nativeInput},
+		{Name: "exit", ArgCount: 1, Params: [][]string{{"int"}}, Function: nativeExit},
+		{Name: "len", ArgCount: 1, Params: [][]string{{"list", "map", "string"}}, Function: nativeLen},
+		{Name: "append", ArgCount: 2, Params: [][]string{{"list"}, {AnyType}}, Function: nativeAppend},
+		{Name: "pop", ArgCount: 1, Params: [][]string{{"list"}}, Function: nativePop},
+		{Name: "keys", ArgCount: 1, Params: [][]string{{"map"}}, Function: nativeKeys},
+		{Name: "delete", ArgCount: 2, Params: [][]string{{"map"}, {AnyType}}, Function: nativeDelete},
+		{Name: "freeze", ArgCount: 1, Params: [][]string{{"list", "map"}}, Function: nativeFreeze},
+		{Name: "int", ArgCount: 1, Params: [][]string{{NumberType}}, Function: nativeInt},
+		{Name: "float", ArgCount: 1, Params: [][]string{{NumberType}}, Function: nativeFloat},
+		{Name: "bigint", ArgCount: 1, Params: [][]string{{NumberType}}, Function: nativeBigInt},
+		{Name: "decimal", ArgCount: 1, Params: [][]string{{NumberType}}, Function: nativeDecimal},
+	}
+)
+
+func init() {
+	for _, native := range builtins {
+		DefineNative(native)
+	}
+}
@@ -0,0 +1,129 @@
+package expr
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"learning/glox/token"
+	"learning/glox/value"
+)
+
+// DefaultMaxDepth call depth of runtime whose Limits.Depth is zero,
+// deeper nesting would overflow the go stack
+const DefaultMaxDepth = 1000
+
+var (
+	// ErrStepLimit cause of RuntimeError when Limits.Steps is exceeded
+	ErrStepLimit = errors.New("step limit exceeded")
+	// ErrStackOverflow cause of RuntimeError when Limits.Depth is exceeded
+	ErrStackOverflow = errors.New("stack overflow")
+	// ErrMemoryLimit cause of RuntimeError when Limits.Alloc is exceeded
+	ErrMemoryLimit = errors.New("memory limit exceeded")
+)
+
+// Limits execution limits of a runtime, zero Steps and Alloc mean unlimited
+type Limits struct {
+	Steps int // operations evaluated, like binary, call and index
+	Depth int // nested calls, unary operators and parens, including calls whose arguments are being evaluated
+	Alloc int // approximate bytes of strings, lists, maps and big numbers created
+}
+
+// Runtime state of one run, checks cancellation and limits while evaluating,
+// errors wrap context.Canceled, context.DeadlineExceeded or one of the Err variables,
+// so caller tells them apart by errors.Is. nil runtime has no limits, optimizer uses it
+type Runtime struct {
+	ctx    context.Context
+	limits Limits
+	stdin  *bufio.Reader // read by input(), nil means no input
+	steps  int
+	depth  int
+	alloc  int
+}
+
+// NewRuntime make runtime of ctx and limits, input() reads lines from stdin,
+// a *bufio.Reader is used as is, so its caller can share buffered lines with input()
+func NewRuntime(ctx context.Context, limits Limits, stdin io.Reader) *Runtime {
+	if limits.Depth == 0 {
+		limits.Depth = DefaultMaxDepth
+	}
+	rt := &Runtime{
+		ctx:    ctx,
+		limits: limits,
+	}
+	if stdin != nil {
+		rt.stdin = bufio.NewReader(stdin)
+	}
+	return rt
+}
+
+// step count one operation at location, fail when canceled or out of steps
+func (rt *Runtime) step(location token.Token) error {
+	if rt == nil {
+		return nil
+	}
+	if err := rt.ctx.Err(); err != nil {
+		return limitError(location, err)
+	}
+	rt.steps++
+	if rt.limits.Steps > 0 && rt.steps > rt.limits.Steps {
+		return limitError(location, ErrStepLimit)
+	}
+	return nil
+}
+
+// enter start a call at location, leave must be called when it returns
+func (rt *Runtime) enter(location token.Token) error {
+	if rt == nil {
+		return nil
+	}
+	if rt.depth >= rt.limits.Depth {
+		return limitError(location, ErrStackOverflow)
+	}
+	rt.depth++
+	return nil
+}
+
+func (rt *Runtime) leave() {
+	if rt != nil {
+		rt.depth--
+	}
+}
+
+// allocate count bytes created at location, fail when out of memory
+func (rt *Runtime) allocate(location token.Token, bytes int) error {
+	if rt == nil {
+		return nil
+	}
+	rt.alloc += bytes
+	if rt.limits.Alloc > 0 && rt.alloc > rt.limits.Alloc {
+		return limitError(location, ErrMemoryLimit)
+	}
+	return nil
+}
+
+func limitError(location token.Token, err error) *RuntimeError {
+	return &RuntimeError{
+		Token:   location,
+		Message: err.Error(),
+		Err:     err,
+	}
+}
+
+// valueSize approximate bytes of one value in list or map
+const valueSize = 64
+
+// sizeOf approximate bytes of string or big number, other values are counted
+// by the list or map expr which creates them
+func sizeOf(v value.Value) int {
+	if str, ok := v.Str(); ok {
+		return len(str)
+	}
+	if bNumber, ok := v.BigInt(); ok {
+		return len(bNumber.Bits()) * 8
+	}
+	if rNumber, ok := v.Decimal(); ok {
+		return (len(rNumber.Num().Bits()) + len(rNumber.Denom().Bits())) * 8
+	}
+	return 0
+}
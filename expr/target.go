@@ -0,0 +1,81 @@
+package expr
+
+import (
+	"learning/glox/token"
+	"learning/glox/value"
+)
+
+// Target expr which can be assigned, eg: object[index]
+type Target interface {
+	Expr
+	// Locate evaluate receiver and index of target exactly once,
+	// the location can be read and written many times
+	Locate(rt *Runtime) (Location, error)
+}
+
+// Location evaluated place of target
+type Location interface {
+	Get() (value.Value, error)
+	Set(v value.Value) error
+}
+
+// indexLocation evaluated object[index]
+type indexLocation struct {
+	bracket token.Token
+	object  value.Object // list or map
+	index   value.Value
+}
+
+// Locate evaluate object and index
+func (i *Index) Locate(rt *Runtime) (Location, error) {
+	object, err := i.Object.Evaluate(rt)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.Index.Evaluate(rt)
+	if err != nil {
+		return nil, err
+	}
+	container, _ := object.Object()
+	switch container.(type) {
+	case *LoxList, *LoxMap:
+		return &indexLocation{
+			bracket: i.Bracket,
+			object:  container,
+			index:   index,
+		}, nil
+	}
+	return nil, &RuntimeError{
+		Token:   i.Bracket,
+		Message: "only lists and maps can be indexed, got " + object.TypeName(),
+	}
+}
+
+// Get read element, negative list index count from the end
+func (l *indexLocation) Get() (value.Value, error) {
+	if list, ok := l.object.(*LoxList); ok {
+		n, err := list.index(l.bracket, l.index)
+		if err != nil {
+			return value.NilValue, err
+		}
+		return list.Elements[n], nil
+	}
+	return l.object.(*LoxMap).get(l.bracket, l.index)
+}
+
+// Set write element, map key is added when missing
+func (l *indexLocation) Set(v value.Value) error {
+	if list, ok := l.object.(*LoxList); ok {
+		err := checkMutable(l.bracket, list)
+		if err != nil {
+			return err
+		}
+		n, err := list.index(l.bracket, l.index)
+		if err != nil {
+			return err
+		}
+		list.Elements[n] = v
+		return nil
+	}
+	return l.object.(*LoxMap).set(l.bracket, l.index, v)
+}
@@ -0,0 +1,139 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext unchanged lines kept around every hunk
+const diffContext = 3
+
+// Diff return unified diff of source and formatted, empty when they are the same
+func Diff(name, source, formatted string) string {
+	if source == formatted {
+		return ""
+	}
+	a := splitLines(source)
+	b := splitLines(formatted)
+	ops := diffLines(a, b)
+
+	res := fmt.Sprintf("--- %s.orig\n+++ %s\n", name, name)
+	for start := 0; start < len(ops); {
+		// find next changed line
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// extend hunk until more than 2*diffContext unchanged lines
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			same := end
+			for same < len(ops) && ops[same].kind == ' ' {
+				same++
+			}
+			if same == len(ops) || same-end > 2*diffContext {
+				break
+			}
+			end = same
+		}
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+		to := end + diffContext
+		if to > len(ops) {
+			to = len(ops)
+		}
+		res += hunk(ops[from:to])
+		start = to
+	}
+	return res
+}
+
+// diffOp one line of diff, kind is ' ', '-' or '+'
+type diffOp struct {
+	kind  byte
+	line  string // with its line ending, the last line of file may have none
+	aLine int // line number in source, start from 1
+	bLine int // line number in formatted, start from 1
+}
+
+func hunk(ops []diffOp) string {
+	aStart, bStart, aCount, bCount := 0, 0, 0, 0
+	body := ""
+	for _, op := range ops {
+		if op.kind != '+' {
+			if aStart == 0 {
+				aStart = op.aLine
+			}
+			aCount++
+		}
+		if op.kind != '-' {
+			if bStart == 0 {
+				bStart = op.bLine
+			}
+			bCount++
+		}
+		body += string(op.kind) + op.line
+		if !strings.HasSuffix(op.line, "\n") {
+			body += "\n\\ No newline at end of file\n"
+		}
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount) + body
+}
+
+// diffLines diff by longest common subsequence of lines
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	res := []diffOp{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			res = append(res, diffOp{kind: ' ', line: a[i], aLine: i + 1, bLine: j + 1})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			res = append(res, diffOp{kind: '-', line: a[i], aLine: i + 1, bLine: j + 1})
+			i++
+		default:
+			res = append(res, diffOp{kind: '+', line: b[j], aLine: i + 1, bLine: j + 1})
+			j++
+		}
+	}
+	return res
+}
+
+// splitLines split s into lines with their line endings, so the last line without one
+// differs from the same line with one, like diff does
+func splitLines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,312 @@
+package formatter
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"learning/glox/expr"
+	"learning/glox/parser"
+	"learning/glox/scanner"
+	"learning/glox/token"
+	"learning/glox/value"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// precedence of binary operator, the larger binds tighter, same levels as parser
+const (
+	precNone int = iota
+	precComma
+	precAssignment
+	precConditional
+	precBitOr
+	precBitXor
+	precBitAnd
+	precEquality
+	precComparison
+	precShift
+	precTerm
+	precFactor
+	precUnary
+	precPower
+	precCall
+	precPrimary
+)
+
+// Source format glox source, every non empty line is one expression,
+// comments are kept, blank lines between expressions collapse to one
+func Source(source string) (string, error) {
+	tokens, comments, err := scanner.ScanTrivia(source)
+	if err != nil {
+		return "", err
+	}
+	lineTokens := parser.Lines(tokens)
+	lineComments := map[int][]token.Token{}
+	for _, item := range comments {
+		lineComments[item.Line] = append(lineComments[item.Line], item)
+	}
+
+	res := []string{}
+	blank := false
+	lines := strings.Count(source, "\n") + 1
+	for line := 1; line <= lines; line++ {
+		formatted, err := formatLine(lineTokens[line], lineComments[line])
+		if err != nil {
+			return "", err
+		}
+		if formatted == "" {
+			blank = len(res) > 0
+			continue
+		}
+		if blank {
+			res = append(res, "")
+			blank = false
+		}
+		res = append(res, formatted)
+	}
+	if len(res) == 0 {
+		return "", nil
+	}
+	return strings.Join(res, "\n") + "\n", nil
+}
+
+// formatLine format one line, expression and its trailing comment
+func formatLine(tokens []token.Token, comments []token.Token) (string, error) {
+	res := ""
+	if len(tokens) > 0 {
+		sExpr, err := parser.ParseLine(tokens)
+		if err != nil {
+			return "", err
+		}
+		res = Expr(sExpr)
+	}
+	for _, comment := range comments {
+		if res != "" {
+			res += " "
+		}
+		res += strings.TrimRight(comment.Lexeme, " \t\r")
+	}
+	return res, nil
+}
+
+// Expr print expr as glox source, with minimal parentheses
+func Expr(e expr.Expr) string {
+	return format(e, precNone, false)
+}
+
+// format print e as operand of an operator with prec, rightSide means e is right operand,
+// left associative operator need parentheses on the right side for the same precedence
+func format(e expr.Expr, prec int, rightSide bool) string {
+	switch node := e.(type) {
+	case *expr.Grouping:
+		// parentheses are added back by precedence, so drop the source ones
+		return format(node.Expression, prec, rightSide)
+	case *expr.Binary:
+		if node.Operator.Type == token.STARSTAR {
+			return power(node, prec)
+		}
+		nodePrec := binaryPrecedence(node.Operator.Type)
+		operator := " " + node.Operator.Lexeme + " "
+		if node.Operator.Type == token.COMMA {
+			operator = ", "
+		}
+		res := format(node.Left, nodePrec, false) +
+			operator +
+			format(node.Right, nodePrec, true)
+		if nodePrec < prec || (nodePrec == prec && rightSide) {
+			return "(" + res + ")"
+		}
+		return res
+	case *expr.Conditional:
+		// right associative, so only the condition needs parentheses at the same precedence
+		res := format(node.Condition, precConditional+1, false) +
+			" ? " + format(node.ThenBranch, precNone, false) +
+			" : " + format(node.ElseBranch, precConditional, false)
+		if precConditional < prec {
+			return "(" + res + ")"
+		}
+		return res
+	case *expr.Unary:
+		operand := format(node.Right, precUnary, false)
+		res := node.Operator.Lexeme + operand
+		if node.Operator.Type == token.MINUS && strings.HasPrefix(operand, "-") {
+			// keep `- -1` apart, so it is never read as `--`
+			res = node.Operator.Lexeme + " " + operand
+		}
+		if precUnary < prec {
+			return "(" + res + ")"
+		}
+		return res
+	case *expr.Assign:
+		// right associative
+		res := format(node.Target, precCall, false) +
+			" " + node.Operator.Lexeme + " " +
+			format(node.Value, precAssignment, false)
+		if precAssignment < prec {
+			return "(" + res + ")"
+		}
+		return res
+	case *expr.Increment:
+		if node.Postfix {
+			return format(node.Target, precCall, false) + node.Operator.Lexeme
+		}
+		res := node.Operator.Lexeme + format(node.Target, precUnary, false)
+		if precUnary < prec {
+			return "(" + res + ")"
+		}
+		return res
+	case *expr.Call:
+		return format(node.Callee, precCall, false) + "(" + list(node.Arguments) + ")"
+	case *expr.Index:
+		return format(node.Object, precCall, false) +
+			"[" + format(node.Index, precAssignment, false) + "]"
+	case *expr.Slice:
+		return format(node.Object, precCall, false) +
+			"[" + bound(node.Start) + ":" + bound(node.End) + "]"
+	case *expr.List:
+		return "[" + list(node.Elements) + "]"
+	case *expr.Map:
+		items := []string{}
+		for i := range node.Keys {
+			items = append(items,
+				format(node.Keys[i], precAssignment, false)+": "+
+					format(node.Values[i], precAssignment, false))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case *expr.Literal:
+		// keep source text, like 1.10d and 007, value would print 1.1d and 7
+		if node.Lexeme != "" {
+			return node.Lexeme
+		}
+		return literal(node.Value)
+	}
+	return e.Visit()
+}
+
+// power format a ** b, right associative, and its right operand is parsed as unary,
+// so `2 ** -1` needs no parentheses but `(-2) ** 2` does
+func power(b *expr.Binary, prec int) string {
+	res := format(b.Left, precPower+1, false) + " ** " + format(b.Right, precUnary, false)
+	if precPower < prec {
+		return "(" + res + ")"
+	}
+	return res
+}
+
+// list format comma separated elements, comma expr needs parentheses in them
+func list(elements []expr.Expr) string {
+	items := []string{}
+	for _, element := range elements {
+		items = append(items, format(element, precAssignment, false))
+	}
+	return strings.Join(items, ", ")
+}
+
+// bound format slice bound, nil literal is a missing bound
+func bound(e expr.Expr) string {
+	if literal, ok := e.(*expr.Literal); ok && literal.Value.IsNil() {
+		return ""
+	}
+	return format(e, precAssignment, false)
+}
+
+func binaryPrecedence(tType token.Type) int {
+	switch tType {
+	case token.COMMA:
+		return precComma
+	case token.BANGEQUAL, token.EQUALEQUAL:
+		return precEquality
+	case token.GREATER, token.GREATEREQUAL, token.LESS, token.LESSEQUAL, token.IN:
+		return precComparison
+	case token.PIPE:
+		return precBitOr
+	case token.CARET:
+		return precBitXor
+	case token.AMPERSAND:
+		return precBitAnd
+	case token.LESSLESS, token.GREATERGREATER:
+		return precShift
+	case token.MINUS, token.PLUS:
+		return precTerm
+	case token.SLASH, token.STAR, token.PERCENT, token.TILDESLASH:
+		return precFactor
+	}
+	return precPrimary
+}
+
+func literal(v value.Value) string {
+	if str, ok := v.Str(); ok {
+		return "\"" + str + "\""
+	}
+	if iNumber, ok := v.Int(); ok {
+		return strconv.FormatInt(iNumber, 10)
+	}
+	if bNumber, ok := v.BigInt(); ok {
+		return bNumber.String() + "n"
+	}
+	if rNumber, ok := v.Decimal(); ok {
+		// literal is never a repeating decimal, it is printed exactly
+		return value.Stringify(value.Decimal(rNumber)) + "d"
+	}
+	if number, ok := v.Number(); ok {
+		// glox has no exponent notation, and float literal needs a fraction
+		res := strconv.FormatFloat(number, 'f', -1, 64)
+		if !strings.Contains(res, ".") {
+			res += ".0"
+		}
+		return res
+	}
+	return value.Stringify(v)
+}
+
+// StartFormat format files like gofmt, args is os.Args,
+// -l list files whose formatting differs, -d print diffs,
+// otherwise print formatted source, read stdin when no file given
+func StartFormat(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	list := flags.Bool("l", false, "list files whose formatting differs from glox fmt")
+	diff := flags.Bool("d", false, "display diffs instead of rewriting files")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		return formatFile(os.Stdout, "<standard input>", data, *list, *diff)
+	}
+	for _, path := range flags.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		err = formatFile(os.Stdout, path, data, *list, *diff)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatFile write formatted source, listed path, or diff of one file to w
+func formatFile(w io.Writer, path string, data []byte, list, diff bool) error {
+	source := string(data)
+	formatted, err := Source(source)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if list && formatted != source {
+		fmt.Fprintln(w, path)
+	}
+	if diff {
+		fmt.Fprint(w, Diff(path, source, formatted))
+	}
+	if !list && !diff {
+		fmt.Fprint(w, formatted)
+	}
+	return nil
+}
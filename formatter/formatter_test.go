@@ -0,0 +1,161 @@
+package formatter
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"1+2", "1 + 2\n"},
+		{"(1+2)*3 // sum", "(1 + 2) * 3 // sum\n"},
+		{"1+(2*3)", "1 + 2 * 3\n"},
+		{"1-(2-3)", "1 - (2 - 3)\n"},
+		{"-(-1)", "- -1\n"},
+		{"!(!true)", "!!true\n"},
+		{"(-2)**2", "(-2) ** 2\n"},
+		{"2**(3**2)", "2 ** 3 ** 2\n"},
+		{"(2**3)**2", "(2 ** 3) ** 2\n"},
+		{"a[0]=(b[1]=2)", "a[0] = b[1] = 2\n"},
+		{"(a?b:c)?d:e", "(a ? b : c) ? d : e\n"},
+		{"f(1,(2,3))", "f(1, (2, 3))\n"},
+		{`{"a":1,2:[3]}`, "{\"a\": 1, 2: [3]}\n"},
+		{"a[1:]", "a[1:]\n"},
+		{"a[0]++ + ++a[1]", "a[0]++ + ++a[1]\n"},
+		// number literals keep their source text
+		{"1.10d+007", "1.10d + 007\n"},
+		{"1.50*2", "1.50 * 2\n"},
+		{"123n", "123n\n"},
+		// blank lines collapse to one, leading and trailing ones are dropped
+		{"\n\n1\n\n\n\n2\n\n", "1\n\n2\n"},
+		{"// only comment\n1", "// only comment\n1\n"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		got, err := Source(test.source)
+		if err != nil {
+			t.Errorf("Source(%q): %v", test.source, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Source(%q) = %q, want %q", test.source, got, test.want)
+		}
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	for _, source := range []string{"1 +", "1 2", "(1", `"abc`} {
+		if _, err := Source(source); err == nil {
+			t.Errorf("Source(%q) want error", source)
+		}
+	}
+}
+
+func TestSourceIdempotent(t *testing.T) {
+	sources := []string{
+		"(1+2)*3 // sum\n\n\n1+(2*3)\n",
+		"-(-1)\n(-2)**2\n2**(3**2)\n(2**3)**2\n",
+		"a[0]=(b[1]=2)\na[0]+=1\na[0]--\n--a[0]\n",
+		"1-(2-3)\n1-2-3\n(1,2),3\n1,(2,3)\n",
+		"!(!true)\n(a?b:c)?d:e\na?(b?c:d):e\n",
+		"{\"a\":1,2:[3]}\nf(1,(2,3))\nf(1)(2)\n",
+		"1.10d+007\n1.50*2\n123n<<2\n",
+		"x in [1,2] == (y in {})\n(a|b)&c\na|b&c\n",
+		"// comment\n\n\n// another\n1 // trailing\n",
+	}
+	for _, source := range sources {
+		once, err := Source(source)
+		if err != nil {
+			t.Errorf("Source(%q): %v", source, err)
+			continue
+		}
+		twice, err := Source(once)
+		if err != nil {
+			t.Errorf("Source(%q): %v", once, err)
+			continue
+		}
+		if once != twice {
+			t.Errorf("Source is not idempotent for %q:\nonce  %q\ntwice %q", source, once, twice)
+		}
+	}
+}
+
+func TestFormatFileList(t *testing.T) {
+	var out bytes.Buffer
+	err := formatFile(&out, "a.lox", []byte("1+2\n"), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "a.lox\n" {
+		t.Errorf("-l of unformatted file = %q, want %q", out.String(), "a.lox\n")
+	}
+
+	out.Reset()
+	err = formatFile(&out, "c.lox", []byte("1 + 2"), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "c.lox\n" {
+		t.Errorf("-l of file without final newline = %q, want %q", out.String(), "c.lox\n")
+	}
+
+	out.Reset()
+	err = formatFile(&out, "b.lox", []byte("1 + 2\n"), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "" {
+		t.Errorf("-l of formatted file = %q, want nothing", out.String())
+	}
+}
+
+func TestFormatFileDiff(t *testing.T) {
+	var out bytes.Buffer
+	source := "1\n2\n3\n4\n5+5\n6\n7\n8\n9\n"
+	err := formatFile(&out, "a.lox", []byte(source), false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a.lox.orig\n+++ a.lox\n" +
+		"@@ -2,7 +2,7 @@\n" +
+		" 2\n 3\n 4\n-5+5\n+5 + 5\n 6\n 7\n 8\n"
+	if out.String() != want {
+		t.Errorf("-d = %q, want %q", out.String(), want)
+	}
+
+	// formatted, except the final newline
+	out.Reset()
+	err = formatFile(&out, "c.lox", []byte("1\n2 + 3"), false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "--- c.lox.orig\n+++ c.lox\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" 1\n-2 + 3\n\\ No newline at end of file\n+2 + 3\n"
+	if out.String() != want {
+		t.Errorf("-d without final newline = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	err = formatFile(&out, "b.lox", []byte("1 + 2\n"), false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "" {
+		t.Errorf("-d of formatted file = %q, want nothing", out.String())
+	}
+}
+
+func TestFormatFile(t *testing.T) {
+	var out bytes.Buffer
+	err := formatFile(&out, "a.lox", []byte("1+2"), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "1 + 2\n" {
+		t.Errorf("format = %q, want %q", out.String(), "1 + 2\n")
+	}
+}
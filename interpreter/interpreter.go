@@ -2,47 +2,133 @@ package interpreter
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"learning/glox/expr"
+	"learning/glox/optimize"
 	"learning/glox/parser"
 	"learning/glox/scanner"
+	"learning/glox/value"
 	"os"
+	"strings"
 
 	"go.uber.org/zap"
-)
-
-var (
-	l *zap.SugaredLogger
+	"go.uber.org/zap/zapcore"
 )
 
 // Interpreter interpreter struct
 type Interpreter struct {
-	Expr expr.Expr
+	Expr   expr.Expr
+	Limits expr.Limits // limits of Evaluate and Run
+	Stdin  io.Reader   // read by input(), nil is os.Stdin
 }
 
-// Evaluate evaluate expr
-func (i *Interpreter) Evaluate() (interface{}, error) {
-	res, err := i.Expr.Evaluate()
+// Evaluate evaluate expr with limits, it is never canceled
+func (i *Interpreter) Evaluate() (value.Value, error) {
+	res, err := i.Expr.Evaluate(i.runtime(context.Background()))
 	if err != nil {
 		return res, err
 	}
 	return res, nil
 }
 
-// StartInterpreter start interpreter, evaluate expr
+func (i *Interpreter) runtime(ctx context.Context) *expr.Runtime {
+	stdin := i.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+	return expr.NewRuntime(ctx, i.Limits, stdin)
+}
+
+// Run evaluate program with limits, every non empty line is one expression, like the REPL,
+// return value of the last one. It stops when ctx is done, or a limit is exceeded,
+// tell them apart with errors.Is and context.Canceled, context.DeadlineExceeded,
+// expr.ErrStepLimit, expr.ErrStackOverflow or expr.ErrMemoryLimit.
+// program is not optimized, so constant folding can not bypass the limits
+func (i *Interpreter) Run(ctx context.Context, program string) (value.Value, error) {
+	tokens, err := scanner.ScanLine(program)
+	if err != nil {
+		return value.NilValue, err
+	}
+	lineTokens := parser.Lines(tokens)
+
+	rt := i.runtime(ctx)
+	res := value.NilValue
+	lines := strings.Count(program, "\n") + 1
+	for line := 1; line <= lines; line++ {
+		if len(lineTokens[line]) == 0 {
+			continue
+		}
+		// literals take no step, check between lines too
+		if err := ctx.Err(); err != nil {
+			return value.NilValue, err
+		}
+		e, err := parser.ParseLine(lineTokens[line])
+		if err != nil {
+			return value.NilValue, err
+		}
+		res, err = e.Evaluate(rt)
+		if err != nil {
+			return value.NilValue, err
+		}
+	}
+	return res, nil
+}
+
+// exitCode code of exit(code), ok is false when err is not from exit
+func exitCode(err error) (int, bool) {
+	var exit *expr.ExitError
+	if errors.As(err, &exit) {
+		return exit.Code, true
+	}
+	return 0, false
+}
+
+// Options io of interpreter, so tests and embedders can capture output,
+// nil fields are os.Stdin, os.Stdout and os.Stderr
+type Options struct {
+	Stdin  io.Reader // read lines of REPL and input()
+	Stdout io.Writer // prompts and results
+	Stderr io.Writer // usage and diagnostics
+}
+
+// StartInterpreter start interpreter with process stdio, evaluate expr
 func StartInterpreter(args []string) {
-	logger, _ := zap.NewDevelopment()
+	code := StartInterpreterWith(args, Options{})
+	if code != 0 {
+		os.Exit(code)
+	}
+}
+
+// StartInterpreterWith start interpreter with options, return code of exit(code),
+// or 0 at end of input
+func StartInterpreterWith(args []string, options Options) int {
+	if options.Stdout == nil {
+		options.Stdout = os.Stdout
+	}
+	if options.Stderr == nil {
+		options.Stderr = os.Stderr
+	}
+	if options.Stdin == nil {
+		options.Stdin = os.Stdin
+	}
+
+	// local, every call reports to its own Stderr
+	logger := newLogger(options.Stderr)
 	defer logger.Sync() // flushes buffer, if any
-	l = logger.Sugar()
+	l := logger.Sugar()
 	// get tokens
 	if len(args) > 2 {
 		l.Info("usage: glox [script]")
-		return
+		return 0
 	}
 
-	reader := bufio.NewReader(os.Stdin)
+	// share reader with input(), so lines it reads are not buffered away
+	reader := bufio.NewReader(options.Stdin)
 	for {
-		fmt.Println("> ")
+		fmt.Fprintln(options.Stdout, "> ")
 		line, _ := reader.ReadString('\n')
 		if line == "" {
 			break
@@ -52,27 +138,37 @@ func StartInterpreter(args []string) {
 			l.Errorf("scanner err: %v", err)
 			continue
 		}
-		parser := parser.Parser{
-			Tokens: tokens,
-		}
-		expr, err := parser.Parse()
-
+		expr, err := parser.ParseLine(tokens)
 		if err != nil {
 			l.Errorf("parse err: %v", err)
 			continue
 		}
 
 		interpreter := Interpreter{
-			Expr: expr,
+			Expr:  optimize.Expr(expr),
+			Stdin: reader,
 		}
 
 		res, err := interpreter.Evaluate()
+		if code, ok := exitCode(err); ok {
+			return code
+		}
 		if err != nil {
 			l.Errorf("eval err: %v", err)
 			continue
 		}
-		fmt.Printf("--eval-- %v\n", res)
+		fmt.Fprintf(options.Stdout, "--eval-- %s\n", value.Stringify(res))
 
 	}
+	return 0
+}
 
+// newLogger development logger like zap.NewDevelopment, writing to w
+func newLogger(w io.Writer) *zap.Logger {
+	core := zapcore.NewCore(
+		zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()),
+		zapcore.AddSync(w),
+		zapcore.DebugLevel,
+	)
+	return zap.New(core, zap.Development(), zap.AddCaller(), zap.AddStacktrace(zapcore.WarnLevel))
 }
@@ -0,0 +1,272 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"learning/glox/expr"
+	"learning/glox/value"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func start(t *testing.T, stdin string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := StartInterpreterWith([]string{"glox"}, Options{
+		Stdin:  strings.NewReader(stdin),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	return code, stdout.String(), stderr.String()
+}
+
+func TestStartInterpreterWith(t *testing.T) {
+	code, stdout, stderr := start(t, "1 + 2\n")
+	if code != 0 {
+		t.Errorf("code = %d, want 0", code)
+	}
+	if stdout != "> \n--eval-- 3\n> \n" {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if stderr != "" {
+		t.Errorf("stderr = %q, want nothing", stderr)
+	}
+}
+
+func TestStartInterpreterWithErrors(t *testing.T) {
+	code, stdout, stderr := start(t, "1 +\n\"a\" - 1\n2\n")
+	if code != 0 {
+		t.Errorf("code = %d, want 0", code)
+	}
+	if !strings.Contains(stdout, "--eval-- 2\n") {
+		t.Errorf("stdout = %q, want result of line after errors", stdout)
+	}
+	if !strings.Contains(stderr, "parse err") || !strings.Contains(stderr, "eval err") {
+		t.Errorf("stderr = %q, want parse and eval errors", stderr)
+	}
+}
+
+// TestStartInterpreterWithConcurrent every instance reports to its own Stdout and Stderr
+func TestStartInterpreterWithConcurrent(t *testing.T) {
+	inputs := []string{
+		strings.Repeat("\"a\" - 1\n", 50),
+		strings.Repeat("1 + 1\n", 50),
+	}
+	stdouts := make([]string, len(inputs))
+	stderrs := make([]string, len(inputs))
+	var wg sync.WaitGroup
+	for n, input := range inputs {
+		wg.Add(1)
+		go func(n int, input string) {
+			defer wg.Done()
+			_, stdouts[n], stderrs[n] = start(t, input)
+		}(n, input)
+	}
+	wg.Wait()
+	if got := strings.Count(stderrs[0], "eval err"); got != 50 {
+		t.Errorf("stderr of failing instance has %d errors, want 50", got)
+	}
+	if strings.Contains(stdouts[0], "--eval--") {
+		t.Errorf("stdout of failing instance = %q, want no results", stdouts[0])
+	}
+	if stderrs[1] != "" {
+		t.Errorf("stderr of other instance = %q, want nothing", stderrs[1])
+	}
+	if got := strings.Count(stdouts[1], "--eval-- 2\n"); got != 50 {
+		t.Errorf("stdout of other instance has %d results, want 50", got)
+	}
+}
+
+// TestStartInterpreterWithTrailingTokens the whole line is one expression, like in Run
+func TestStartInterpreterWithTrailingTokens(t *testing.T) {
+	for _, line := range []string{"1.5n", "1e3", "2 3", "(1) 2"} {
+		_, stdout, stderr := start(t, line+"\n")
+		if strings.Contains(stdout, "--eval--") {
+			t.Errorf("%s: stdout = %q, want no result", line, stdout)
+		}
+		if !strings.Contains(stderr, "expect end of expression") {
+			t.Errorf("%s: stderr = %q, want parse error", line, stderr)
+		}
+		i := Interpreter{}
+		if _, err := i.Run(context.Background(), line); err == nil ||
+			!strings.Contains(err.Error(), "expect end of expression") {
+			t.Errorf("%s: Run error = %v, want parse error", line, err)
+		}
+	}
+}
+
+func TestStartInterpreterWithExit(t *testing.T) {
+	code, stdout, _ := start(t, "exit(3)\n1\n")
+	if code != 3 {
+		t.Errorf("code = %d, want 3", code)
+	}
+	if strings.Contains(stdout, "--eval--") {
+		t.Errorf("stdout = %q, want nothing evaluated after exit", stdout)
+	}
+}
+
+// TestStartInterpreterWithInput input() reads lines of the REPL's stdin,
+// every call has its own, so one does not read the input of another
+func TestStartInterpreterWithInput(t *testing.T) {
+	_, stdout, _ := start(t, "input()\nfirst\n")
+	if !strings.Contains(stdout, "--eval-- first\n") {
+		t.Errorf("stdout = %q, want line read by input()", stdout)
+	}
+	_, stdout, _ = start(t, "input()\n")
+	if !strings.Contains(stdout, "--eval-- nil\n") {
+		t.Errorf("stdout = %q, want nil at end of input", stdout)
+	}
+}
+
+func TestRunStdin(t *testing.T) {
+	i := Interpreter{
+		Stdin: strings.NewReader("a\nb\nc\n"),
+	}
+	res, err := i.Run(context.Background(), "input()\ninput() + input()")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !value.Equals(res, value.String("bc")) {
+		t.Errorf("Run = %s, want bc", value.Stringify(res))
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  expr.Limits
+		program string
+		want    error
+	}{
+		{"steps", expr.Limits{Steps: 1}, "1 + 2\n3 + 4", expr.ErrStepLimit},
+		{"steps of calls", expr.Limits{Steps: 10}, strings.Repeat("len(\"a\")\n", 20), expr.ErrStepLimit},
+		{"depth", expr.Limits{Depth: 3}, "str(str(str(str(1))))", expr.ErrStackOverflow},
+		{"default depth", expr.Limits{}, strings.Repeat("str(", 2000) + "1" + strings.Repeat(")", 2000),
+			expr.ErrStackOverflow},
+		{"depth of unary", expr.Limits{Depth: 3}, "!!!!1", expr.ErrStackOverflow},
+		{"depth of parens", expr.Limits{Depth: 3}, "((((1))))", expr.ErrStackOverflow},
+		// nesting deeper than parser.MaxDepth fails to parse, before it overflows the go stack
+		{"parse depth of unary", expr.Limits{}, strings.Repeat("!", 100000) + "1", expr.ErrStackOverflow},
+		{"parse depth of parens", expr.Limits{}, strings.Repeat("(", 5000) + "1" + strings.Repeat(")", 5000),
+			expr.ErrStackOverflow},
+		{"parse depth of lists", expr.Limits{}, strings.Repeat("[", 5000) + strings.Repeat("]", 5000),
+			expr.ErrStackOverflow},
+		{"parse depth of operators", expr.Limits{}, strings.Repeat("1 + ", 5000) + "1", expr.ErrStackOverflow},
+		{"parse depth of index", expr.Limits{}, "[0]" + strings.Repeat("[0]", 5000), expr.ErrStackOverflow},
+		{"parse depth of conditional", expr.Limits{}, strings.Repeat("true ? 1 : ", 5000) + "1",
+			expr.ErrStackOverflow},
+		{"memory", expr.Limits{Alloc: 100}, `"` + strings.Repeat("a", 60) + `" + "` + strings.Repeat("b", 60) + `"`,
+			expr.ErrMemoryLimit},
+		{"memory of list", expr.Limits{Alloc: 1000}, "[" + strings.Repeat("1, ", 100) + "1]", expr.ErrMemoryLimit},
+		// counted before the power is computed, it would not finish otherwise
+		{"memory of bigint power", expr.Limits{Alloc: 1 << 20}, "2n ** 100000000000n", expr.ErrMemoryLimit},
+		{"memory of promoted power", expr.Limits{Alloc: 1 << 20}, "3 ** 100000000000n", expr.ErrMemoryLimit},
+		{"memory of decimal power", expr.Limits{Alloc: 1 << 20}, "1.5d ** -100000000000", expr.ErrMemoryLimit},
+	}
+	for _, test := range tests {
+		i := Interpreter{
+			Limits: test.limits,
+		}
+		_, err := i.Run(context.Background(), test.program)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: Run error = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestRunWithinLimits(t *testing.T) {
+	i := Interpreter{
+		Limits: expr.Limits{Steps: 10, Depth: 4, Alloc: 1 << 10},
+	}
+	res, err := i.Run(context.Background(), "str(str(str(1)))\n2n ** 64\n-(-1)\n1n ** 100000000000n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !value.Equals(res, value.Int(1)) {
+		t.Errorf("Run = %s, want 1", value.Stringify(res))
+	}
+}
+
+// TestRunNested nesting within parser.MaxDepth and the default depth is fine
+func TestRunNested(t *testing.T) {
+	i := Interpreter{}
+	programs := []string{
+		strings.Repeat("(", 500) + "1" + strings.Repeat(")", 500),
+		strings.Repeat("- ", 500) + "1",
+		strings.Repeat("1 + ", 500) + "1",
+	}
+	for _, program := range programs {
+		if _, err := i.Run(context.Background(), program); err != nil {
+			t.Errorf("Run of %.20s...: %v", program, err)
+		}
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	i := Interpreter{}
+	_, err := i.Run(ctx, "1 + 2")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run error = %v, want %v", err, context.Canceled)
+	}
+	// literals take no step
+	_, err = i.Run(ctx, "1")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRunDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	// every line takes a while, all of them take far longer than the deadline
+	program := strings.Repeat("3n ** 2000000n > 0\n", 100)
+	i := Interpreter{}
+	_, err := i.Run(ctx, program)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// TestRunDeadlineOfPower a power whose result is over the memory limit fails
+// at once, instead of running past the deadline
+func TestRunDeadlineOfPower(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	i := Interpreter{
+		Limits: expr.Limits{Alloc: 1 << 20},
+	}
+	_, err := i.Run(ctx, "2n ** 100000000000n")
+	if !errors.Is(err, expr.ErrMemoryLimit) {
+		t.Errorf("Run error = %v, want %v", err, expr.ErrMemoryLimit)
+	}
+}
+
+// TestRunConcurrent Run is safe for concurrent use, every run has its own runtime
+func TestRunConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			i := Interpreter{
+				Stdin:  strings.NewReader(fmt.Sprintf("%d\n", n)),
+				Limits: expr.Limits{Steps: 100},
+			}
+			res, err := i.Run(context.Background(), "[1, 2][0] + 1\nnum(input()) * 2")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !value.Equals(res, value.Int(int64(n*2))) {
+				t.Errorf("run %d = %s, want %d", n, value.Stringify(res), n*2)
+			}
+		}(n)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,226 @@
+package optimize
+
+import (
+	"learning/glox/expr"
+	"learning/glox/token"
+)
+
+// Expr fold constant expr and simplify identities, the result evaluates
+// to the same value, or fails with the same error, as e
+func Expr(e expr.Expr) expr.Expr {
+	switch node := e.(type) {
+	case *expr.Grouping:
+		// grouping only matters to the parser, evaluation is the same without it
+		return Expr(node.Expression)
+	case *expr.Unary:
+		return unary(&expr.Unary{
+			Operator: node.Operator,
+			Right:    Expr(node.Right),
+		})
+	case *expr.Binary:
+		return binary(&expr.Binary{
+			Left:     Expr(node.Left),
+			Operator: node.Operator,
+			Right:    Expr(node.Right),
+		})
+	case *expr.Conditional:
+		return conditional(&expr.Conditional{
+			Condition:  Expr(node.Condition),
+			ThenBranch: Expr(node.ThenBranch),
+			ElseBranch: Expr(node.ElseBranch),
+		})
+	case *expr.Call:
+		return &expr.Call{
+			Callee:    Expr(node.Callee),
+			Paren:     node.Paren,
+			Arguments: exprs(node.Arguments),
+		}
+	case *expr.List:
+		return &expr.List{
+			Bracket:  node.Bracket,
+			Elements: exprs(node.Elements),
+		}
+	case *expr.Map:
+		return &expr.Map{
+			Brace:  node.Brace,
+			Keys:   exprs(node.Keys),
+			Values: exprs(node.Values),
+		}
+	case *expr.Index:
+		return &expr.Index{
+			Object:  Expr(node.Object),
+			Bracket: node.Bracket,
+			Index:   Expr(node.Index),
+		}
+	case *expr.Slice:
+		return &expr.Slice{
+			Object:  Expr(node.Object),
+			Bracket: node.Bracket,
+			Start:   Expr(node.Start),
+			End:     Expr(node.End),
+		}
+	case *expr.Assign:
+		return &expr.Assign{
+			Target:   target(node.Target),
+			Operator: node.Operator,
+			Value:    Expr(node.Value),
+		}
+	case *expr.Increment:
+		return &expr.Increment{
+			Target:   target(node.Target),
+			Operator: node.Operator,
+			Postfix:  node.Postfix,
+		}
+	}
+	return e
+}
+
+// target optimize receiver and index of target, it stays assignable
+func target(t expr.Target) expr.Target {
+	if optimized, ok := Expr(t).(expr.Target); ok {
+		return optimized
+	}
+	return t
+}
+
+func exprs(list []expr.Expr) []expr.Expr {
+	res := []expr.Expr{}
+	for _, item := range list {
+		res = append(res, Expr(item))
+	}
+	return res
+}
+
+func conditional(c *expr.Conditional) expr.Expr {
+	// a literal condition always picks the same branch
+	literal, ok := c.Condition.(*expr.Literal)
+	if !ok {
+		return c
+	}
+	if literal.Value.Truthy() {
+		return c.ThenBranch
+	}
+	return c.ElseBranch
+}
+
+func unary(u *expr.Unary) expr.Expr {
+	if folded, ok := fold(u, u.Right); ok {
+		return folded
+	}
+	// !!b is b, when b is already a bool
+	inner, ok := u.Right.(*expr.Unary)
+	if ok && u.Operator.Type == token.BANG &&
+		inner.Operator.Type == token.BANG && isBool(inner.Right) {
+		return inner.Right
+	}
+	return u
+}
+
+func binary(b *expr.Binary) expr.Expr {
+	if folded, ok := fold(b, b.Left, b.Right); ok {
+		return folded
+	}
+	// operands are evaluated left to right, and the literal side can not fail,
+	// so the kept side fails with the same error as before.
+	// x + 0 is not simplified, -0 + 0 is 0, not -0,
+	// x / 1 is not simplified, int / int is float
+	switch b.Operator.Type {
+	case token.COMMA:
+		// a literal has no side effect, drop it
+		if _, ok := b.Left.(*expr.Literal); ok {
+			return b.Right
+		}
+	case token.STAR:
+		if isNumber(b.Left) && isLiteral(b.Right, 1) {
+			return b.Left
+		}
+		if isLiteral(b.Left, 1) && isNumber(b.Right) {
+			return b.Right
+		}
+	case token.MINUS:
+		if isNumber(b.Left) && isLiteral(b.Right, 0) {
+			return b.Left
+		}
+	}
+	return b
+}
+
+// fold evaluate e at compile time, when all operands are literal,
+// e is kept when evaluation fails, so the error still happens at runtime
+func fold(e expr.Expr, operands ...expr.Expr) (expr.Expr, bool) {
+	for _, operand := range operands {
+		if _, isLit := operand.(*expr.Literal); !isLit {
+			return e, false
+		}
+	}
+	value, err := e.Evaluate(nil)
+	if err != nil {
+		return e, false
+	}
+	return &expr.Literal{
+		Value: value,
+	}, true
+}
+
+// isNumber report whether e always evaluates to int or float, or fails
+func isNumber(e expr.Expr) bool {
+	switch node := e.(type) {
+	case *expr.Literal:
+		_, ok := node.Value.Number()
+		return ok
+	case *expr.Grouping:
+		return isNumber(node.Expression)
+	case *expr.Conditional:
+		return isNumber(node.ThenBranch) && isNumber(node.ElseBranch)
+	case *expr.Unary:
+		return node.Operator.Type == token.MINUS || node.Operator.Type == token.TILDE
+	case *expr.Increment:
+		return true
+	case *expr.Binary:
+		switch node.Operator.Type {
+		case token.MINUS, token.STAR, token.SLASH, token.PERCENT, token.STARSTAR,
+			token.TILDESLASH, token.AMPERSAND, token.PIPE, token.CARET,
+			token.LESSLESS, token.GREATERGREATER:
+			return true
+		case token.PLUS:
+			return isNumber(node.Left) && isNumber(node.Right)
+		case token.COMMA:
+			return isNumber(node.Right)
+		}
+	}
+	return false
+}
+
+// isBool report whether e always evaluates to bool, or fails
+func isBool(e expr.Expr) bool {
+	switch node := e.(type) {
+	case *expr.Literal:
+		_, ok := node.Value.Bool()
+		return ok
+	case *expr.Grouping:
+		return isBool(node.Expression)
+	case *expr.Conditional:
+		return isBool(node.ThenBranch) && isBool(node.ElseBranch)
+	case *expr.Unary:
+		return node.Operator.Type == token.BANG
+	case *expr.Binary:
+		switch node.Operator.Type {
+		case token.BANGEQUAL, token.EQUALEQUAL,
+			token.GREATER, token.GREATEREQUAL, token.LESS, token.LESSEQUAL, token.IN:
+			return true
+		case token.COMMA:
+			return isBool(node.Right)
+		}
+	}
+	return false
+}
+
+// isLiteral report whether e is int literal of value, float literal would turn int x into float
+func isLiteral(e expr.Expr, value int64) bool {
+	literal, ok := e.(*expr.Literal)
+	if !ok {
+		return false
+	}
+	iValue, ok := literal.Value.Int()
+	return ok && iValue == value
+}
@@ -0,0 +1,122 @@
+package optimize
+
+import (
+	"learning/glox/expr"
+	"learning/glox/parser"
+	"learning/glox/scanner"
+	"learning/glox/value"
+	"math/big"
+	"testing"
+)
+
+func parse(t *testing.T, source string) expr.Expr {
+	t.Helper()
+	tokens, err := scanner.ScanLine(source)
+	if err != nil {
+		t.Fatalf("scan %q: %v", source, err)
+	}
+	e, err := parser.ParseLine(tokens)
+	if err != nil {
+		t.Fatalf("parse %q: %v", source, err)
+	}
+	return e
+}
+
+// resetGlobals define fresh operands for every evaluation, so assignment in one
+// evaluation does not change the other, -x of them is known numeric to optimizer
+func resetGlobals() {
+	list := func(items ...value.Value) value.Value {
+		return value.FromObject(&expr.LoxList{Elements: items})
+	}
+	expr.Globals["ints"] = list(value.Int(5), value.Int(0))
+	expr.Globals["floats"] = list(value.Number(2.5), value.Number(0))
+	expr.Globals["bigs"] = list(value.BigInt(big.NewInt(7)))
+	expr.Globals["decs"] = list(value.Decimal(big.NewRat(11, 10)))
+	expr.Globals["strs"] = list(value.String("s"))
+}
+
+// evaluate value and error text of e
+func evaluate(e expr.Expr) (string, string) {
+	resetGlobals()
+	res, err := e.Evaluate(nil)
+	if err != nil {
+		return "", err.Error()
+	}
+	return value.Stringify(res) + " " + res.TypeName(), ""
+}
+
+// TestExprDifferential optimized expr evaluates to the same value, or the same error
+func TestExprDifferential(t *testing.T) {
+	defer func() {
+		for _, name := range []string{"ints", "floats", "bigs", "decs", "strs"} {
+			delete(expr.Globals, name)
+		}
+	}()
+	sources := []string{
+		// folding
+		"1 + 2 * 3", "1 / 2", "4 / 2", "7 ~/ 2", "2 ** 10", "2n ** 70", "1.10d + 2", "1d / 3d",
+		`"a" + "b"`, "[1, 2][0]", "1 < 2.5", "1 == 1.0", "-(-1)", "~0",
+		// failing operands stay failing at runtime
+		`"a" - 1`, "1 ~/ 0", "1 % 0", "9223372036854775807 + 1", "-9223372036854775807 - 2",
+		"1.5d + 1.5", "nil < 1", "[1][5]", "1 << 64", "len(1)", "undefined * 1",
+		// x * 1, 1 * x, x - 0 keep int, float, bigint and decimal
+		"-ints[0] * 1", "1 * -ints[0]", "-ints[0] - 0",
+		"-floats[0] * 1", "1 * -floats[0]", "-floats[0] - 0",
+		"-bigs[0] * 1", "1 * -bigs[0]", "-bigs[0] - 0",
+		"-decs[0] * 1", "1 * -decs[0]", "-decs[0] - 0",
+		"-ints[0] * 1.0", "-ints[0] / 1", "-decs[0] * 1.0",
+		// -0
+		"-floats[1]", "-floats[1] * 1", "-floats[1] - 0", "-floats[1] + 0", "0 + -floats[1]",
+		"-0.0", "-0.0 * 1", "-0.0 + 0",
+		// failing operand of identity
+		"-strs[0] * 1", "1 * -strs[0]", "-strs[0] - 0", "-undefined * 1",
+		// !!b
+		"!!true", "!!(1 < 2)", "!!ints[0]", "!!nil", "!!(ints[0] == 5)",
+		// comma with literal left
+		"1, ints[0]", `"x", -ints[0]`, "(1, 2), 3", "ints[0], 1", "1, strs[0] - 1",
+		// conditional with literal condition
+		"true ? ints[0] : strs[0] - 1", "nil ? 1 : -floats[0]", "ints[0] ? 1 : 2",
+		// side effects happen once, in order
+		"ints[0] = ints[0] * 1", "ints[0]++ * 1", "-(ints[0] += 1) * 1", "(1, ints[1]++), ints[1]",
+	}
+	for _, source := range sources {
+		e := parse(t, source)
+		optimized := Expr(e)
+		want, wantErr := evaluate(e)
+		got, gotErr := evaluate(optimized)
+		if got != want || gotErr != wantErr {
+			t.Errorf("%s => %s: got (%q, %q), want (%q, %q)",
+				source, optimized.Visit(), got, gotErr, want, wantErr)
+		}
+	}
+}
+
+// TestExprSimplify identities are applied, not only harmless
+func TestExprSimplify(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"1 + 2 * 3", "7"},
+		{"(1 + 2)", "3"},
+		{"-ints[0] * 1", "(- (index ints 0))"},
+		{"1 * -floats[0]", "(- (index floats 0))"},
+		{"-bigs[0] - 0", "(- (index bigs 0))"},
+		{"!!(ints[0] == 5)", "(== (index ints 0) 5)"},
+		{"1, ints[0]", "(index ints 0)"},
+		{"true ? ints[0] : 2", "(index ints 0)"},
+		// not simplified, they change value or type
+		{"-floats[0] + 0", "(+ (- (index floats 0)) 0)"},
+		{"-ints[0] / 1", "(/ (- (index ints 0)) 1)"},
+		{"-ints[0] * 1.0", "(* (- (index ints 0)) 1.0)"},
+		{"!!ints[0]", "(! (! (index ints 0)))"},
+		{"ints[0] * 1", "(* (index ints 0) 1)"},
+		{`"a" - 1`, `(- "a" 1)`},
+	}
+	for _, test := range tests {
+		got := Expr(parse(t, test.source)).Visit()
+		if got != test.want {
+			t.Errorf("Expr(%s) = %s, want %s", test.source, got, test.want)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"learning/glox/expr"
 	"learning/glox/scanner"
 	"learning/glox/token"
+	"learning/glox/value"
+	"math/big"
 	"os"
 
 	"go.uber.org/zap"
@@ -16,10 +18,16 @@ var (
 	l *zap.SugaredLogger
 )
 
+// MaxDepth deepest nesting of parens, brackets, braces, unary operators, conditionals,
+// assignments and operator chains like 1 + 2 + 3, deeper expr would overflow the go stack
+// of parser, evaluator and printers
+const MaxDepth = expr.DefaultMaxDepth
+
 // Parser parser source code
 type Parser struct {
 	Tokens  []token.Token // parser token slice
 	Current int           // parser current location
+	depth   int           // nesting of expression being parsed
 }
 
 // StartParse start parse
@@ -46,11 +54,7 @@ func StartParse(args []string) {
 			l.Errorf("scanner err: %v", err)
 			continue
 		}
-		parser := Parser{
-			Tokens: tokens,
-		}
-		expr, err := parser.Parse()
-
+		expr, err := ParseLine(tokens)
 		if err != nil {
 			l.Errorf("parse err: %v", err)
 			continue
@@ -61,6 +65,7 @@ func StartParse(args []string) {
 		}
 
 		ast.Print()
+		ast.PrintRPN()
 	}
 
 }
@@ -71,17 +76,237 @@ func (p *Parser) Parse() (expr.Expr, error) {
 
 }
 
+// Lines group tokens by line, EOF is dropped, every non empty line is one expression
+// of a program, like Interpreter.Run and glox fmt read it
+func Lines(tokens []token.Token) map[int][]token.Token {
+	res := map[int][]token.Token{}
+	for _, item := range tokens {
+		if item.Type != token.EOF {
+			res[item.Line] = append(res[item.Line], item)
+		}
+	}
+	return res
+}
+
+// ParseLine parse tokens of one line as one expression, EOF is added when it is missing,
+// every token must be consumed, so `2 3` is an error instead of 2
+func ParseLine(tokens []token.Token) (expr.Expr, error) {
+	if len(tokens) == 0 || tokens[len(tokens)-1].Type != token.EOF {
+		line := 1
+		if len(tokens) > 0 {
+			line = tokens[len(tokens)-1].Line
+		}
+		tokens = append(tokens[:len(tokens):len(tokens)], token.Token{
+			Type: token.EOF,
+			Line: line,
+		})
+	}
+	p := Parser{
+		Tokens: tokens,
+	}
+	e, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	if !p.IsAtEnd() {
+		return nil, p.error(p.Peek(), "expect end of expression")
+	}
+	return e, nil
+}
+
 func (p *Parser) expression() (expr.Expr, error) {
-	return p.equality()
+	return p.comma()
+}
+
+// comma c comma operator, evaluate left to right, result is the rightmost
+func (p *Parser) comma() (expr.Expr, error) {
+	defer p.leave(p.depth)
+	sExpr, err := p.assignment()
+	if err != nil {
+		return nil, err
+	}
+	for p.Match(token.COMMA) {
+		operator := p.Previous()
+		err = p.enter()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		sExpr = &expr.Binary{
+			Left:     sExpr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+	return sExpr, nil
+}
+
+// assignment target = value, or compound target += value, right associative
+func (p *Parser) assignment() (expr.Expr, error) {
+	defer p.leave(p.depth)
+	err := p.enter()
+	if err != nil {
+		return nil, err
+	}
+	sExpr, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	if p.Match(token.EQUAL, token.PLUSEQUAL, token.MINUSEQUAL,
+		token.STAREQUAL, token.SLASHEQUAL, token.PERCENTEQUAL) {
+		operator := p.Previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		target, err := p.target(sExpr, operator)
+		if err != nil {
+			return nil, err
+		}
+		return &expr.Assign{
+			Target:   target,
+			Operator: operator,
+			Value:    value,
+		}, nil
+	}
+	return sExpr, nil
+}
+
+// target check e can be assigned by operator
+func (p *Parser) target(e expr.Expr, operator token.Token) (expr.Target, error) {
+	target, ok := e.(expr.Target)
+	if !ok {
+		return nil, p.error(operator, "invalid assignment target")
+	}
+	return target, nil
+}
+
+// conditional cond ? a : b, right associative
+func (p *Parser) conditional() (expr.Expr, error) {
+	sExpr, err := p.bitOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.Match(token.QUESTION) {
+		defer p.leave(p.depth)
+		err = p.enter()
+		if err != nil {
+			return nil, err
+		}
+		thenBranch, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(token.COLON, "expect ':' after then branch of conditional expression")
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		sExpr = &expr.Conditional{
+			Condition:  sExpr,
+			ThenBranch: thenBranch,
+			ElseBranch: elseBranch,
+		}
+	}
+	return sExpr, nil
+}
+
+// bitOr bitwise or, like c it binds looser than equality
+func (p *Parser) bitOr() (expr.Expr, error) {
+	defer p.leave(p.depth)
+	sExpr, err := p.bitXor()
+	if err != nil {
+		return nil, err
+	}
+	for p.Match(token.PIPE) {
+		operator := p.Previous()
+		err = p.enter()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.bitXor()
+		if err != nil {
+			return nil, err
+		}
+		sExpr = &expr.Binary{
+			Left:     sExpr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+	return sExpr, nil
+}
+
+// bitXor bitwise xor
+func (p *Parser) bitXor() (expr.Expr, error) {
+	defer p.leave(p.depth)
+	sExpr, err := p.bitAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.Match(token.CARET) {
+		operator := p.Previous()
+		err = p.enter()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.bitAnd()
+		if err != nil {
+			return nil, err
+		}
+		sExpr = &expr.Binary{
+			Left:     sExpr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+	return sExpr, nil
+}
+
+// bitAnd bitwise and
+func (p *Parser) bitAnd() (expr.Expr, error) {
+	defer p.leave(p.depth)
+	sExpr, err := p.equality()
+	if err != nil {
+		return nil, err
+	}
+	for p.Match(token.AMPERSAND) {
+		operator := p.Previous()
+		err = p.enter()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.equality()
+		if err != nil {
+			return nil, err
+		}
+		sExpr = &expr.Binary{
+			Left:     sExpr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+	return sExpr, nil
 }
 
 func (p *Parser) equality() (expr.Expr, error) {
+	defer p.leave(p.depth)
 	sExpr, err := p.comparison()
 	if err != nil {
 		return nil, err
 	}
 	for p.Match(token.BANGEQUAL, token.EQUALEQUAL) {
 		operator := p.Previous()
+		err = p.enter()
+		if err != nil {
+			return nil, err
+		}
 		right, err := p.comparison()
 		if err != nil {
 			return nil, err
@@ -96,12 +321,44 @@ func (p *Parser) equality() (expr.Expr, error) {
 }
 
 func (p *Parser) comparison() (expr.Expr, error) {
+	defer p.leave(p.depth)
+	sExpr, err := p.shift()
+	if err != nil {
+		return nil, err
+	}
+	for p.Match(token.GREATER, token.GREATEREQUAL, token.LESS, token.LESSEQUAL, token.IN) {
+		operator := p.Previous()
+		err = p.enter()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.shift()
+		if err != nil {
+			return nil, err
+		}
+		sExpr = &expr.Binary{
+			Left:     sExpr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return sExpr, nil
+}
+
+// shift left and right shift
+func (p *Parser) shift() (expr.Expr, error) {
+	defer p.leave(p.depth)
 	sExpr, err := p.term()
 	if err != nil {
 		return nil, err
 	}
-	for p.Match(token.GREATER, token.GREATEREQUAL, token.LESS, token.LESSEQUAL) {
+	for p.Match(token.LESSLESS, token.GREATERGREATER) {
 		operator := p.Previous()
+		err = p.enter()
+		if err != nil {
+			return nil, err
+		}
 		right, err := p.term()
 		if err != nil {
 			return nil, err
@@ -112,17 +369,21 @@ func (p *Parser) comparison() (expr.Expr, error) {
 			Right:    right,
 		}
 	}
-
 	return sExpr, nil
 }
 
 func (p *Parser) term() (expr.Expr, error) {
+	defer p.leave(p.depth)
 	sExpr, err := p.factor()
 	if err != nil {
 		return nil, err
 	}
 	for p.Match(token.MINUS, token.PLUS) {
 		operator := p.Previous()
+		err = p.enter()
+		if err != nil {
+			return nil, err
+		}
 		right, err := p.factor()
 		if err != nil {
 			return nil, err
@@ -137,12 +398,17 @@ func (p *Parser) term() (expr.Expr, error) {
 }
 
 func (p *Parser) factor() (expr.Expr, error) {
+	defer p.leave(p.depth)
 	sExpr, err := p.unary()
 	if err != nil {
 		return nil, err
 	}
-	for p.Match(token.SLASH, token.STAR) {
+	for p.Match(token.SLASH, token.STAR, token.PERCENT, token.TILDESLASH) {
 		operator := p.Previous()
+		err = p.enter()
+		if err != nil {
+			return nil, err
+		}
 		right, err := p.unary()
 		if err != nil {
 			return nil, err
@@ -157,8 +423,13 @@ func (p *Parser) factor() (expr.Expr, error) {
 }
 
 func (p *Parser) unary() (expr.Expr, error) {
-	if p.Match(token.BANG, token.MINUS) {
+	if p.Match(token.BANG, token.MINUS, token.TILDE) {
 		operator := p.Previous()
+		defer p.leave(p.depth)
+		err := p.enter()
+		if err != nil {
+			return nil, err
+		}
 		right, err := p.unary()
 		if err != nil {
 			return nil, err
@@ -168,35 +439,239 @@ func (p *Parser) unary() (expr.Expr, error) {
 			Right:    right,
 		}, nil
 	}
+	if p.Match(token.PLUSPLUS, token.MINUSMINUS) {
+		operator := p.Previous()
+		defer p.leave(p.depth)
+		err := p.enter()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		target, err := p.target(right, operator)
+		if err != nil {
+			return nil, err
+		}
+		return &expr.Increment{
+			Target:   target,
+			Operator: operator,
+		}, nil
+	}
 
-	return p.primary()
+	return p.power()
+}
+
+// power a ** b, right associative, binds tighter than unary on the left, -2 ** 2 is -4
+func (p *Parser) power() (expr.Expr, error) {
+	defer p.leave(p.depth)
+	sExpr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+	if p.Match(token.STARSTAR) {
+		operator := p.Previous()
+		err = p.enter()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		sExpr = &expr.Binary{
+			Left:     sExpr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+	return sExpr, nil
+}
+
+// call callee(arguments), object[index], object[start:end], target++ or target--
+func (p *Parser) call() (expr.Expr, error) {
+	defer p.leave(p.depth)
+	sExpr, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.Match(token.LEFTPAREN) {
+			err = p.enter()
+			if err != nil {
+				return nil, err
+			}
+			arguments, err := p.elements(token.RIGHTPAREN)
+			if err != nil {
+				return nil, err
+			}
+			paren, err := p.consume(token.RIGHTPAREN, "expect ')' after arguments")
+			if err != nil {
+				return nil, err
+			}
+			sExpr = &expr.Call{
+				Callee:    sExpr,
+				Paren:     paren,
+				Arguments: arguments,
+			}
+		} else if p.Match(token.LEFTBRACKET) {
+			err = p.enter()
+			if err != nil {
+				return nil, err
+			}
+			sExpr, err = p.index(sExpr)
+			if err != nil {
+				return nil, err
+			}
+		} else if p.Match(token.PLUSPLUS, token.MINUSMINUS) {
+			operator := p.Previous()
+			target, err := p.target(sExpr, operator)
+			if err != nil {
+				return nil, err
+			}
+			sExpr = &expr.Increment{
+				Target:   target,
+				Operator: operator,
+				Postfix:  true,
+			}
+		} else {
+			break
+		}
+	}
+	return sExpr, nil
+}
+
+// index parse rest of object[index] or object[start:end], left bracket is consumed
+func (p *Parser) index(object expr.Expr) (expr.Expr, error) {
+	bracket := p.Previous()
+	var (
+		start expr.Expr = &expr.Literal{Value: value.NilValue}
+		end   expr.Expr = &expr.Literal{Value: value.NilValue}
+		err   error
+	)
+	if !p.Check(token.COLON) {
+		start, err = p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		if !p.Check(token.COLON) {
+			_, err = p.consume(token.RIGHTBRACKET, "expect ']' after index")
+			if err != nil {
+				return nil, err
+			}
+			return &expr.Index{
+				Object:  object,
+				Bracket: bracket,
+				Index:   start,
+			}, nil
+		}
+	}
+	p.Advance()
+	if !p.Check(token.RIGHTBRACKET) {
+		end, err = p.assignment()
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = p.consume(token.RIGHTBRACKET, "expect ']' after slice")
+	if err != nil {
+		return nil, err
+	}
+	return &expr.Slice{
+		Object:  object,
+		Bracket: bracket,
+		Start:   start,
+		End:     end,
+	}, nil
+}
+
+// elements parse comma separated expressions until closing token, closing token is not consumed
+func (p *Parser) elements(closing token.Type) ([]expr.Expr, error) {
+	res := []expr.Expr{}
+	if p.Check(closing) {
+		return res, nil
+	}
+	for {
+		element, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, element)
+		if !p.Match(token.COMMA) {
+			return res, nil
+		}
+	}
 }
 
 func (p *Parser) primary() (expr.Expr, error) {
 	if p.Match(token.FALSE) {
 		return &expr.Literal{
-			Value: false,
+			Value: value.FalseValue,
 		}, nil
 	}
 
 	if p.Match(token.TRUE) {
 		return &expr.Literal{
-			Value: true,
+			Value: value.TrueValue,
 		}, nil
 	}
 
 	if p.Match(token.NIL) {
 		return &expr.Literal{
-			Value: nil,
+			Value: value.NilValue,
+		}, nil
+	}
+
+	if p.Match(token.NUMBER) {
+		var literal value.Value
+		switch number := p.Previous().Literal.(type) {
+		case int64:
+			literal = value.Int(number)
+		case *big.Int:
+			literal = value.BigInt(number)
+		case *big.Rat:
+			literal = value.Decimal(number)
+		default:
+			literal = value.Number(number.(float64))
+		}
+		return &expr.Literal{
+			Value:  literal,
+			Lexeme: p.Previous().Lexeme,
 		}, nil
 	}
 
-	if p.Match(token.NUMBER, token.STRING) {
+	if p.Match(token.STRING) {
 		return &expr.Literal{
-			Value: p.Previous().Literal,
+			Value: value.String(p.Previous().Literal.(string)),
+		}, nil
+	}
+	if p.Match(token.IDENTIFIER) {
+		return &expr.Variable{
+			Name: p.Previous(),
 		}, nil
 	}
+	if p.Match(token.LEFTBRACKET) {
+		bracket := p.Previous()
+		elements, err := p.elements(token.RIGHTBRACKET)
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(token.RIGHTBRACKET, "expect ']' after list elements")
+		if err != nil {
+			return nil, err
+		}
+		return &expr.List{
+			Bracket:  bracket,
+			Elements: elements,
+		}, nil
+	}
+	if p.Match(token.LEFTBRACE) {
+		// there is no block statement, so brace in expression is always a map
+		return p.mapLiteral()
+	}
 	if p.Match(token.LEFTPAREN) {
+		paren := p.Previous()
 		sExpr, err := p.expression()
 		if err != nil {
 			return nil, err
@@ -206,27 +681,82 @@ func (p *Parser) primary() (expr.Expr, error) {
 			return nil, err
 		}
 		return &expr.Grouping{
+			Paren:      paren,
 			Expression: sExpr,
 		}, nil
 	}
 
-	return nil, nil
+	return nil, p.error(p.Peek(), "expect expression")
 
 }
 
+// mapLiteral parse rest of {key: value, ...}, left brace is consumed
+func (p *Parser) mapLiteral() (expr.Expr, error) {
+	res := &expr.Map{
+		Brace:  p.Previous(),
+		Keys:   []expr.Expr{},
+		Values: []expr.Expr{},
+	}
+	for !p.Check(token.RIGHTBRACE) {
+		key, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(token.COLON, "expect ':' after map key")
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		res.Keys = append(res.Keys, key)
+		res.Values = append(res.Values, value)
+		if !p.Match(token.COMMA) {
+			break
+		}
+	}
+	_, err := p.consume(token.RIGHTBRACE, "expect '}' after map entries")
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 func (p *Parser) consume(tType token.Type, message string) (token.Token, error) {
 	if p.Check(tType) {
 		return p.Advance(), nil
 	}
-	pToken := p.Peek()
+	return token.Token{}, p.error(p.Peek(), message)
+}
+
+// enter start nested expression, or one more operand of an operator chain, which nests
+// the tree as deep, fail when it is deeper than MaxDepth, the error wraps
+// expr.ErrStackOverflow like the one of evaluation, leave restores depth when it is parsed
+func (p *Parser) enter() error {
+	if p.depth >= MaxDepth {
+		// at the token which nests, like the operator or the paren
+		return fmt.Errorf("[line %d] Error at '%s': expression nested too deeply, %w",
+			p.Previous().Line, p.Previous().Lexeme, expr.ErrStackOverflow)
+	}
+	p.depth++
+	return nil
+}
+
+func (p *Parser) leave(depth int) {
+	p.depth = depth
+}
+
+// error make parse error at token location
+func (p *Parser) error(pToken token.Token, message string) error {
 	if pToken.Type == token.EOF {
-		return token.Token{}, fmt.Errorf(
+		return fmt.Errorf(
 			"[line %d] Error %s: %s",
 			pToken.Line,
 			"at end",
 			message)
 	}
-	return token.Token{}, fmt.Errorf(
+	return fmt.Errorf(
 		"[line %d] Error %s: %s",
 		pToken.Line,
 		"at '"+pToken.Lexeme+"'",
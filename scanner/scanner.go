@@ -4,39 +4,52 @@ import (
 	"bufio"
 	"fmt"
 	"learning/glox/token"
+	"math/big"
 	"os"
 	"strconv"
 
 	"go.uber.org/zap"
 )
 
-var (
-	l *zap.SugaredLogger
-)
-
 // Scanner 扫描器
 type Scanner struct {
-	source  string        // source code
-	runes   []rune        // source code rune slice
-	tokens  []token.Token // tokens
-	line    int           // location
-	start   int           // token start location
-	current int           // token current location
+	source   string             // source code
+	runes    []rune             // source code rune slice
+	tokens   []token.Token      // tokens
+	comments []token.Token      // comment trivia, not in tokens
+	line     int                // location
+	start    int                // token start location
+	current  int                // token current location
+	logger   *zap.SugaredLogger // logs tokens of StartScanner and errors
 }
 
-// ScanLine start scanner
+// ScanLine start scanner, errors are returned instead of logged,
+// so caller decides where to report them
 func ScanLine(line string) ([]token.Token, error) {
 
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync() // flushes buffer, if any
-	l = logger.Sugar()
 	s := Scanner{
 		line:    1,
 		start:   0,
 		current: 0,
+		logger:  zap.NewNop().Sugar(),
 	}
-	s.run(line, false)
-	return s.tokens, nil
+	err := s.run(line, false)
+	return s.tokens, err
+
+}
+
+// ScanTrivia scan line like ScanLine, also return comments,
+// so formatter can write them back, errors are returned, not logged
+func ScanTrivia(line string) ([]token.Token, []token.Token, error) {
+
+	s := Scanner{
+		line:    1,
+		start:   0,
+		current: 0,
+		logger:  zap.NewNop().Sugar(),
+	}
+	err := s.run(line, false)
+	return s.tokens, s.comments, err
 
 }
 
@@ -47,11 +60,11 @@ func StartScanner(args []string) ([]token.Token, error) {
 	)
 	logger, _ := zap.NewDevelopment()
 	defer logger.Sync() // flushes buffer, if any
-	l = logger.Sugar()
 	s := Scanner{
 		line:    1,
 		start:   0,
 		current: 0,
+		logger:  logger.Sugar(),
 	}
 	// 2. if only one arg, is source file name
 	if len(args) == 2 {
@@ -104,16 +117,17 @@ func (s *Scanner) run(source string, print bool) error {
 	s.source = source
 	s.runes = []rune(source)
 	s.tokens = []token.Token{}
+	s.comments = []token.Token{}
 	s.start = 0
 	s.current = 0
-	s.line = 0
+	s.line = 1
 	err = s.scanTokens()
 	if err != nil {
 		return err
 	}
 	if print {
 		for _, token := range s.tokens {
-			l.Info(token)
+			s.logger.Info(token)
 		}
 	}
 	return nil
@@ -158,18 +172,62 @@ func (s *Scanner) scanToken() error {
 		s.addToken(token.LEFTBRACE)
 	case '}':
 		s.addToken(token.RIGHTBRACE)
+	case '[':
+		s.addToken(token.LEFTBRACKET)
+	case ']':
+		s.addToken(token.RIGHTBRACKET)
 	case ',':
 		s.addToken(token.COMMA)
 	case '.':
 		s.addToken(token.DOT)
 	case '-':
-		s.addToken(token.MINUS)
+		if s.match('-') {
+			s.addToken(token.MINUSMINUS)
+		} else if s.match('=') {
+			s.addToken(token.MINUSEQUAL)
+		} else {
+			s.addToken(token.MINUS)
+		}
 	case '+':
-		s.addToken(token.PLUS)
+		if s.match('+') {
+			s.addToken(token.PLUSPLUS)
+		} else if s.match('=') {
+			s.addToken(token.PLUSEQUAL)
+		} else {
+			s.addToken(token.PLUS)
+		}
 	case ';':
 		s.addToken(token.SEMICOLON)
 	case '*':
-		s.addToken(token.STAR)
+		if s.match('*') {
+			s.addToken(token.STARSTAR)
+		} else if s.match('=') {
+			s.addToken(token.STAREQUAL)
+		} else {
+			s.addToken(token.STAR)
+		}
+	case '%':
+		if s.match('=') {
+			s.addToken(token.PERCENTEQUAL)
+		} else {
+			s.addToken(token.PERCENT)
+		}
+	case '&':
+		s.addToken(token.AMPERSAND)
+	case '|':
+		s.addToken(token.PIPE)
+	case '^':
+		s.addToken(token.CARET)
+	case '~':
+		if s.match('/') {
+			s.addToken(token.TILDESLASH)
+		} else {
+			s.addToken(token.TILDE)
+		}
+	case '?':
+		s.addToken(token.QUESTION)
+	case ':':
+		s.addToken(token.COLON)
 	case '!':
 		if s.match('=') {
 			s.addToken(token.BANGEQUAL)
@@ -185,12 +243,16 @@ func (s *Scanner) scanToken() error {
 	case '<':
 		if s.match('=') {
 			s.addToken(token.LESSEQUAL)
+		} else if s.match('<') {
+			s.addToken(token.LESSLESS)
 		} else {
 			s.addToken(token.LESS)
 		}
 	case '>':
 		if s.match('=') {
 			s.addToken(token.GREATEREQUAL)
+		} else if s.match('>') {
+			s.addToken(token.GREATERGREATER)
 		} else {
 			s.addToken(token.GREATER)
 		}
@@ -201,7 +263,10 @@ func (s *Scanner) scanToken() error {
 			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
+			s.addComment()
 
+		} else if s.match('=') {
+			s.addToken(token.SLASHEQUAL)
 		} else {
 			s.addToken(token.SLASH)
 		}
@@ -212,15 +277,15 @@ func (s *Scanner) scanToken() error {
 
 	case '"':
 		// string
-		s.addString()
+		return s.addString()
 	default:
 		if s.isDigit(c) {
-			s.addNumber()
+			return s.addNumber()
 		} else if s.isAlpha(c) {
 			s.addIdentifier()
 
 		} else {
-			l.Errorf("unexpected character.")
+			s.logger.Errorf("unexpected character.")
 			return fmt.Errorf("unexpected character.")
 		}
 	}
@@ -274,8 +339,17 @@ func (s *Scanner) addTokenWithValue(tType token.Type, value interface{}) {
 	s.tokens = append(s.tokens, token)
 }
 
+// addComment keep comment as trivia, parser never see it
+func (s *Scanner) addComment() {
+	s.comments = append(s.comments, token.Token{
+		Type:   token.COMMENT,
+		Lexeme: string(s.runes[s.start:s.current]),
+		Line:   s.line,
+	})
+}
+
 // get string value
-func (s *Scanner) addString() {
+func (s *Scanner) addString() error {
 	for s.peek() != '"' && !s.isAtEnd() {
 		if s.peek() == '\n' {
 			s.line++
@@ -284,13 +358,14 @@ func (s *Scanner) addString() {
 	}
 
 	if s.isAtEnd() {
-		l.Error("unterminal string")
-		return
+		s.logger.Error("unterminal string")
+		return fmt.Errorf("[line %d] Error: unterminated string", s.line)
 	}
 
 	s.advance()
-	value := s.runes[s.start+1 : s.current-1]
+	value := string(s.runes[s.start+1 : s.current-1])
 	s.addTokenWithValue(token.STRING, value)
+	return nil
 }
 
 // number
@@ -298,13 +373,17 @@ func (s *Scanner) isDigit(r rune) bool {
 	return r >= '0' && r <= '9'
 }
 
-func (s *Scanner) addNumber() {
+// addNumber literal without fraction is int64, with fraction is float64,
+// suffix n makes *big.Int, like 123n, suffix d makes decimal *big.Rat, like 1.10d
+func (s *Scanner) addNumber() error {
 
 	for s.isDigit(s.peek()) {
 		s.advance()
 	}
 
+	fraction := false
 	if s.peek() == '.' && s.isDigit(s.peekNext()) {
+		fraction = true
 		s.advance()
 
 		for s.isDigit(s.peek()) {
@@ -314,9 +393,36 @@ func (s *Scanner) addNumber() {
 
 	value := string(s.runes[s.start:s.current])
 
-	fValue, _ := strconv.ParseFloat(value, 64)
-	s.addTokenWithValue(token.NUMBER, fValue)
+	if s.isSuffix('d') {
+		s.advance()
+		rValue, _ := new(big.Rat).SetString(value)
+		s.addTokenWithValue(token.NUMBER, rValue)
+		return nil
+	}
+	if !fraction && s.isSuffix('n') {
+		s.advance()
+		bValue, _ := new(big.Int).SetString(value, 10)
+		s.addTokenWithValue(token.NUMBER, bValue)
+		return nil
+	}
+
+	if fraction {
+		fValue, _ := strconv.ParseFloat(value, 64)
+		s.addTokenWithValue(token.NUMBER, fValue)
+		return nil
+	}
+
+	iValue, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("[line %d] Error at '%s': integer literal overflows int64", s.line, value)
+	}
+	s.addTokenWithValue(token.NUMBER, iValue)
+	return nil
+}
 
+// isSuffix check next char is number suffix, and not start of identifier, like 1name
+func (s *Scanner) isSuffix(suffix rune) bool {
+	return s.peek() == suffix && !s.isAlphaNumeric(s.peekNext())
 }
 
 // identifier
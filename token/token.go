@@ -20,6 +20,7 @@ var (
 		"for":    FOR,
 		"fun":    FUN,
 		"if":     IF,
+		"in":     IN,
 		"nil":    NIL,
 		"or":     OR,
 		"print":  PRINT,
@@ -14,6 +14,10 @@ const (
 	LEFTBRACE
 	// RIGHTBRACE }
 	RIGHTBRACE
+	// LEFTBRACKET [
+	LEFTBRACKET
+	// RIGHTBRACKET ]
+	RIGHTBRACKET
 	// COMMA ,
 	COMMA
 	// DOT . dot
@@ -28,6 +32,18 @@ const (
 	SLASH
 	// STAR *
 	STAR
+	// QUESTION ?
+	QUESTION
+	// COLON :
+	COLON
+	// PERCENT %
+	PERCENT
+	// AMPERSAND &
+	AMPERSAND
+	// PIPE |
+	PIPE
+	// CARET ^
+	CARET
 
 	// 2. one or two char token
 
@@ -47,6 +63,30 @@ const (
 	LESS
 	// LESSEQUAL <=
 	LESSEQUAL
+	// STARSTAR ** power
+	STARSTAR
+	// TILDE ~ bitwise not
+	TILDE
+	// TILDESLASH ~/ integer division, // is comment
+	TILDESLASH
+	// LESSLESS << left shift
+	LESSLESS
+	// GREATERGREATER >> right shift
+	GREATERGREATER
+	// PLUSEQUAL +=
+	PLUSEQUAL
+	// MINUSEQUAL -=
+	MINUSEQUAL
+	// STAREQUAL *=
+	STAREQUAL
+	// SLASHEQUAL /=
+	SLASHEQUAL
+	// PERCENTEQUAL %=
+	PERCENTEQUAL
+	// PLUSPLUS ++
+	PLUSPLUS
+	// MINUSMINUS --
+	MINUSMINUS
 
 	// text
 
@@ -56,6 +96,8 @@ const (
 	STRING
 	// NUMBER number
 	NUMBER
+	// COMMENT comment, only kept as scanner trivia, never passed to parser
+	COMMENT
 
 	// keyword keyword
 
@@ -73,6 +115,8 @@ const (
 	FOR
 	// IF if
 	IF
+	// IN in
+	IN
 	// NIL nil
 	NIL
 	// OR or
@@ -109,6 +153,10 @@ func (tokenType Type) String() string {
 		res = "left_brace"
 	case RIGHTBRACE:
 		res = "right_brace"
+	case LEFTBRACKET:
+		res = "left_bracket"
+	case RIGHTBRACKET:
+		res = "right_bracket"
 	case COMMA:
 		res = "comma"
 	case DOT:
@@ -123,6 +171,18 @@ func (tokenType Type) String() string {
 		res = "slash"
 	case STAR:
 		res = "star"
+	case QUESTION:
+		res = "question"
+	case COLON:
+		res = "colon"
+	case PERCENT:
+		res = "percent"
+	case AMPERSAND:
+		res = "ampersand"
+	case PIPE:
+		res = "pipe"
+	case CARET:
+		res = "caret"
 	case BANG:
 		res = "bang"
 	case BANGEQUAL:
@@ -139,12 +199,38 @@ func (tokenType Type) String() string {
 		res = "less"
 	case LESSEQUAL:
 		res = "less_equal"
+	case STARSTAR:
+		res = "star_star"
+	case TILDE:
+		res = "tilde"
+	case TILDESLASH:
+		res = "tilde_slash"
+	case LESSLESS:
+		res = "less_less"
+	case GREATERGREATER:
+		res = "greater_greater"
+	case PLUSEQUAL:
+		res = "plus_equal"
+	case MINUSEQUAL:
+		res = "minus_equal"
+	case STAREQUAL:
+		res = "star_equal"
+	case SLASHEQUAL:
+		res = "slash_equal"
+	case PERCENTEQUAL:
+		res = "percent_equal"
+	case PLUSPLUS:
+		res = "plus_plus"
+	case MINUSMINUS:
+		res = "minus_minus"
 	case IDENTIFIER:
 		res = "identifier"
 	case STRING:
 		res = "string"
 	case NUMBER:
 		res = "number"
+	case COMMENT:
+		res = "comment"
 	case AND:
 		res = "and"
 	case CLASS:
@@ -159,6 +245,8 @@ func (tokenType Type) String() string {
 		res = "for"
 	case IF:
 		res = "if"
+	case IN:
+		res = "in"
 	case NIL:
 		res = "nil"
 	case OR:
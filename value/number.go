@@ -0,0 +1,165 @@
+package value
+
+import (
+	"math"
+	"math/big"
+	"strings"
+)
+
+// decimalDigits fraction digits printed for decimals which do not terminate, like 1d / 3d
+const decimalDigits = 32
+
+// bigInt bigint boxed in object of Value
+type bigInt struct {
+	*big.Int
+}
+
+// TypeName type name of bigint
+func (b bigInt) TypeName() string {
+	return "bigint"
+}
+
+// decimal decimal boxed in object of Value
+type decimal struct {
+	*big.Rat
+}
+
+func (d decimal) String() string {
+	return formatDecimal(d.Rat)
+}
+
+// TypeName type name of decimal
+func (d decimal) TypeName() string {
+	return "decimal"
+}
+
+// float float64 payload, only for NumberKind
+func (v Value) float() float64 {
+	return math.Float64frombits(v.bits)
+}
+
+// int int64 payload, only for IntKind
+func (v Value) int() int64 {
+	return int64(v.bits)
+}
+
+// bigInt bigint payload, only for BigIntKind
+func (v Value) bigInt() *big.Int {
+	return v.object.(bigInt).Int
+}
+
+// decimal decimal payload, only for DecimalKind
+func (v Value) decimal() *big.Rat {
+	return v.object.(decimal).Rat
+}
+
+// IsNumber check value is int, float, bigint or decimal
+func (v Value) IsNumber() bool {
+	switch v.kind {
+	case NumberKind, IntKind, BigIntKind, DecimalKind:
+		return true
+	}
+	return false
+}
+
+// IsBig check value is bigint or decimal
+func (v Value) IsBig() bool {
+	return v.kind == BigIntKind || v.kind == DecimalKind
+}
+
+// Rat get exact rational of number, ok is false when value is not a number, or is NaN or infinity
+func (v Value) Rat() (*big.Rat, bool) {
+	switch v.kind {
+	case IntKind:
+		return new(big.Rat).SetInt64(v.int()), true
+	case BigIntKind:
+		return new(big.Rat).SetInt(v.bigInt()), true
+	case DecimalKind:
+		return v.decimal(), true
+	case NumberKind:
+		f := v.float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(f), true
+	}
+	return nil, false
+}
+
+// Compare compare numbers of any kind exactly, res is -1, 0 or 1,
+// ok is false when either is not a number, or is NaN
+func Compare(a, b Value) (res int, ok bool) {
+	if !a.IsNumber() || !b.IsNumber() {
+		return 0, false
+	}
+	aInf, bInf := infinity(a), infinity(b)
+	if aInf == 0 && bInf == 0 {
+		aRat, aok := a.Rat()
+		bRat, bok := b.Rat()
+		if !aok || !bok {
+			// NaN
+			return 0, false
+		}
+		return aRat.Cmp(bRat), true
+	}
+	switch {
+	case aInf < bInf:
+		return -1, true
+	case aInf > bInf:
+		return 1, true
+	}
+	return 0, true
+}
+
+// infinity sign of infinite float, 0 for other numbers
+func infinity(v Value) int {
+	if v.kind == NumberKind && math.IsInf(v.float(), 0) {
+		if v.float() > 0 {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
+
+// formatDecimal print decimal without exponent, with at least one fraction digit,
+// exactly when it terminates, otherwise rounded to decimalDigits
+func formatDecimal(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String() + ".0"
+	}
+	// denominator of terminating decimal has only 2 and 5 as factors,
+	// digits needed is the larger count of them
+	denominator := new(big.Int).Set(r.Denom())
+	twos, fives := 0, 0
+	two, five, zero := big.NewInt(2), big.NewInt(5), big.NewInt(0)
+	remainder := new(big.Int)
+	for {
+		quotient, rem := new(big.Int).QuoRem(denominator, two, remainder)
+		if rem.Cmp(zero) != 0 {
+			break
+		}
+		denominator = quotient
+		twos++
+	}
+	for {
+		quotient, rem := new(big.Int).QuoRem(denominator, five, remainder)
+		if rem.Cmp(zero) != 0 {
+			break
+		}
+		denominator = quotient
+		fives++
+	}
+	if denominator.IsInt64() && denominator.Int64() == 1 {
+		digits := twos
+		if fives > digits {
+			digits = fives
+		}
+		return r.FloatString(digits)
+	}
+	res := strings.TrimRight(r.FloatString(decimalDigits), "0")
+	if strings.HasSuffix(res, ".") {
+		res += "0"
+	}
+	return res
+}
@@ -0,0 +1,312 @@
+package value
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Kind value kind
+type Kind int
+
+const (
+	// NilKind nil
+	NilKind Kind = iota
+	// BoolKind true or false
+	BoolKind
+	// NumberKind float64 number
+	NumberKind
+	// StringKind string
+	StringKind
+	// ObjectKind list, map, function...
+	ObjectKind
+	// IntKind int64 number, made by integer literals
+	IntKind
+	// BigIntKind arbitrary-precision integer, made by literals like 123n
+	BigIntKind
+	// DecimalKind exact rational number, made by literals like 1.10d
+	DecimalKind
+)
+
+func (k Kind) String() string {
+	var (
+		res string
+	)
+	switch k {
+	case NilKind:
+		res = "nil"
+	case BoolKind:
+		res = "bool"
+	case NumberKind:
+		res = "float"
+	case IntKind:
+		res = "int"
+	case BigIntKind:
+		res = "bigint"
+	case DecimalKind:
+		res = "decimal"
+	case StringKind:
+		res = "string"
+	case ObjectKind:
+		res = "object"
+	default:
+		res = "unknown"
+	}
+	return res
+}
+
+// Object heap value, like list, map and function
+type Object interface {
+	String() string
+	TypeName() string
+}
+
+// Equaler object which defines its own equality, objects without it are equal when identical
+type Equaler interface {
+	Equals(other Object) bool
+}
+
+// Value glox runtime value, a tagged union, so numbers and bools are not boxed,
+// bigint and decimal are boxed in object, so Value stays small
+type Value struct {
+	kind   Kind
+	bits   uint64 // float64 bits, int64, or 1 for true
+	str    string
+	object Object
+}
+
+var (
+	// NilValue the nil value, also the zero Value
+	NilValue = Value{}
+	// TrueValue true
+	TrueValue = Value{kind: BoolKind, bits: 1}
+	// FalseValue false
+	FalseValue = Value{kind: BoolKind}
+)
+
+// Nil make nil value
+func Nil() Value {
+	return NilValue
+}
+
+// Bool make bool value
+func Bool(b bool) Value {
+	if b {
+		return TrueValue
+	}
+	return FalseValue
+}
+
+// Number make number value
+func Number(n float64) Value {
+	return Value{kind: NumberKind, bits: math.Float64bits(n)}
+}
+
+// Int make int value
+func Int(n int64) Value {
+	return Value{kind: IntKind, bits: uint64(n)}
+}
+
+// BigInt make bigint value, n must not be mutated later
+func BigInt(n *big.Int) Value {
+	return Value{kind: BigIntKind, object: bigInt{n}}
+}
+
+// Decimal make decimal value, r must not be mutated later
+func Decimal(r *big.Rat) Value {
+	return Value{kind: DecimalKind, object: decimal{r}}
+}
+
+// String make string value
+func String(s string) Value {
+	return Value{kind: StringKind, str: s}
+}
+
+// FromObject make object value
+func FromObject(o Object) Value {
+	return Value{kind: ObjectKind, object: o}
+}
+
+// Kind value kind
+func (v Value) Kind() Kind {
+	return v.kind
+}
+
+// IsNil check value is nil
+func (v Value) IsNil() bool {
+	return v.kind == NilKind
+}
+
+// Bool get bool, ok is false when value is not a bool
+func (v Value) Bool() (bool, bool) {
+	return v.bits != 0, v.kind == BoolKind
+}
+
+// Number get number as float64, other numeric kinds are converted, maybe inexactly,
+// ok is false when value is not a number
+func (v Value) Number() (float64, bool) {
+	switch v.kind {
+	case IntKind:
+		return float64(v.int()), true
+	case BigIntKind:
+		res, _ := new(big.Float).SetInt(v.bigInt()).Float64()
+		return res, true
+	case DecimalKind:
+		res, _ := v.decimal().Float64()
+		return res, true
+	}
+	if v.kind != NumberKind {
+		return 0, false
+	}
+	return v.float(), true
+}
+
+// Int get int, ok is false when value is not an int, floats are not converted
+func (v Value) Int() (int64, bool) {
+	if v.kind != IntKind {
+		return 0, false
+	}
+	return v.int(), true
+}
+
+// BigInt get bigint, ok is false when value is not a bigint, ints are not converted
+func (v Value) BigInt() (*big.Int, bool) {
+	if v.kind != BigIntKind {
+		return nil, false
+	}
+	return v.bigInt(), true
+}
+
+// Decimal get decimal, ok is false when value is not a decimal
+func (v Value) Decimal() (*big.Rat, bool) {
+	if v.kind != DecimalKind {
+		return nil, false
+	}
+	return v.decimal(), true
+}
+
+// IsFloat check value is a float
+func (v Value) IsFloat() bool {
+	return v.kind == NumberKind
+}
+
+// Str get string, ok is false when value is not a string
+func (v Value) Str() (string, bool) {
+	return v.str, v.kind == StringKind
+}
+
+// Object get object, ok is false when value is not an object
+func (v Value) Object() (Object, bool) {
+	return v.object, v.kind == ObjectKind
+}
+
+// TypeName name of value type, used by error messages
+func (v Value) TypeName() string {
+	if v.kind == ObjectKind {
+		return v.object.TypeName()
+	}
+	return v.kind.String()
+}
+
+// Truthy false and nil are falsey, everything else is truthy
+func (v Value) Truthy() bool {
+	switch v.kind {
+	case NilKind:
+		return false
+	case BoolKind:
+		return v.bits != 0
+	}
+	return true
+}
+
+// String implement fmt.Stringer with Stringify
+func (v Value) String() string {
+	return Stringify(v)
+}
+
+// Stringify print value for the REPL and str(), like jlox stringify, except integral floats
+// keep ".0", jlox strips it, glox has ints, so 1.0 and 1 must be told apart
+func Stringify(v Value) string {
+	switch v.kind {
+	case NilKind:
+		return "nil"
+	case BoolKind:
+		if v.bits != 0 {
+			return "true"
+		}
+		return "false"
+	case NumberKind:
+		return formatNumber(v.float())
+	case IntKind:
+		return strconv.FormatInt(v.int(), 10)
+	case BigIntKind:
+		return v.bigInt().String()
+	case DecimalKind:
+		return formatDecimal(v.decimal())
+	case StringKind:
+		return v.str
+	}
+	return v.object.String()
+}
+
+// formatNumber print float like java Double.toString, which jlox uses: shortest digits,
+// no exponent in [1e-3, 1e7), like 1234567.0 and 0.001, otherwise like 1.2345678E7
+// and 1.0E-4, and Infinity, -Infinity and NaN
+func formatNumber(n float64) string {
+	switch {
+	case math.IsNaN(n):
+		return "NaN"
+	case math.IsInf(n, 1):
+		return "Infinity"
+	case math.IsInf(n, -1):
+		return "-Infinity"
+	}
+	abs := math.Abs(n)
+	if abs == 0 || (abs >= 1e-3 && abs < 1e7) {
+		return withFraction(strconv.FormatFloat(n, 'f', -1, 64))
+	}
+	// go prints 1.2345678E+07, java 1.2345678E7
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(n, 'E', -1, 64), "E")
+	power, _ := strconv.Atoi(exponent)
+	return withFraction(mantissa) + "E" + strconv.Itoa(power)
+}
+
+// withFraction add ".0" to number without fraction digits
+func withFraction(number string) string {
+	if !strings.Contains(number, ".") {
+		return number + ".0"
+	}
+	return number
+}
+
+// Equals glox equality, values of different kinds are never equal, except numbers
+// of the same value, NaN is not equal to itself, and -0 equals 0, like float comparison
+func Equals(a, b Value) bool {
+	if a.kind != b.kind || a.kind == BigIntKind || a.kind == DecimalKind {
+		res, ok := Compare(a, b)
+		return ok && res == 0
+	}
+	switch a.kind {
+	case NilKind:
+		return true
+	case BoolKind, IntKind:
+		return a.bits == b.bits
+	case NumberKind:
+		return a.float() == b.float()
+	case StringKind:
+		return a.str == b.str
+	}
+	if equaler, ok := a.object.(Equaler); ok {
+		return equaler.Equals(b.object)
+	}
+	return a.object == b.object
+}
+
+// IntOf get exact int64 of integral float, ok is false when f is not integral or out of range
+func IntOf(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
@@ -0,0 +1,124 @@
+package value
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"unsafe"
+)
+
+func TestStringify(t *testing.T) {
+	// variables, constant 0.1 + 0.2 is exactly 0.3
+	tenth, fifth := 0.1, 0.2
+	tests := []struct {
+		v    Value
+		want string
+	}{
+		{Nil(), "nil"},
+		{Bool(true), "true"},
+		{Bool(false), "false"},
+		{String("a b"), "a b"},
+		{Int(0), "0"},
+		{Int(-42), "-42"},
+		{Int(math.MaxInt64), "9223372036854775807"},
+		{Number(1), "1.0"},
+		{Number(math.Copysign(0, -1)), "-0.0"},
+		{Number(123.456), "123.456"},
+		{Number(-0.001), "-0.001"},
+		{Number(tenth + fifth), "0.30000000000000004"},
+		// like java Double.toString
+		{Number(100), "100.0"},
+		{Number(9999999), "9999999.0"},
+		{Number(123456.789), "123456.789"},
+		{Number(0.001), "0.001"},
+		{Number(10000000), "1.0E7"},
+		{Number(12345678), "1.2345678E7"},
+		{Number(-12345678.9), "-1.23456789E7"},
+		{Number(0.0001), "1.0E-4"},
+		{Number(-1.5e-5), "-1.5E-5"},
+		{Number(1e21), "1.0E21"},
+		{Number(math.Pow(2, 70)), "1.1805916207174113E21"},
+		{Number(math.MaxFloat64), "1.7976931348623157E308"},
+		{Number(math.Inf(1)), "Infinity"},
+		{Number(math.Inf(-1)), "-Infinity"},
+		{Number(math.NaN()), "NaN"},
+		{BigInt(new(big.Int).Lsh(big.NewInt(1), 70)), "1180591620717411303424"},
+		{Decimal(big.NewRat(11, 10)), "1.1"},
+		{Decimal(big.NewRat(2, 1)), "2.0"},
+		{Decimal(big.NewRat(1, 1000000000)), "0.000000001"},
+		{Decimal(big.NewRat(1, 3)), "0.33333333333333333333333333333333"},
+		{Decimal(big.NewRat(-2, 3)), "-0.66666666666666666666666666666667"},
+	}
+	for _, test := range tests {
+		if got := Stringify(test.v); got != test.want {
+			t.Errorf("Stringify(%s) = %q, want %q", test.v.TypeName(), got, test.want)
+		}
+	}
+}
+
+func TestEquals(t *testing.T) {
+	nan := Number(math.NaN())
+	big2p53 := BigInt(new(big.Int).Lsh(big.NewInt(1), 53))
+	tests := []struct {
+		a, b Value
+		want bool
+	}{
+		{Nil(), Nil(), true},
+		{Nil(), Bool(false), false},
+		{Bool(true), Bool(true), true},
+		{Bool(true), Bool(false), false},
+		{Int(-1), Int(-1), true},
+		{Int(1), Int(2), false},
+		{String("a"), String("a"), true},
+		{String("1"), Int(1), false},
+		{nan, nan, false},
+		{Number(0), Number(math.Copysign(0, -1)), true},
+		{Int(1), Number(1), true},
+		{Int(9007199254740993), Number(9007199254740992), false},
+		{Int(1), BigInt(big.NewInt(1)), true},
+		{Number(0.5), Decimal(big.NewRat(1, 2)), true},
+		{Number(0.1), Decimal(big.NewRat(1, 10)), false},
+		{big2p53, Number(9007199254740992), true},
+		{Number(math.Inf(1)), Number(math.Inf(1)), true},
+		{Number(math.Inf(1)), big2p53, false},
+	}
+	for _, test := range tests {
+		if got := Equals(test.a, test.b); got != test.want {
+			t.Errorf("Equals(%s, %s) = %v, want %v", test.a, test.b, got, test.want)
+		}
+		if got := Equals(test.b, test.a); got != test.want {
+			t.Errorf("Equals(%s, %s) = %v, want %v", test.b, test.a, got, test.want)
+		}
+	}
+}
+
+// TestValueSize numbers and bools are not boxed, bigint and decimal share the object slot
+func TestValueSize(t *testing.T) {
+	if size := unsafe.Sizeof(Value{}); size > 48 {
+		t.Errorf("Value is %d bytes, want at most 48", size)
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	if _, ok := Int(1).BigInt(); ok {
+		t.Error("int is a bigint")
+	}
+	if _, ok := String("s").Decimal(); ok {
+		t.Error("string is a decimal")
+	}
+	if n, ok := Bool(true).Number(); ok || n != 0 {
+		t.Errorf("Number() of true = %v, %v", n, ok)
+	}
+	if n, ok := Number(1).Int(); ok || n != 0 {
+		t.Errorf("Int() of 1.0 = %v, %v", n, ok)
+	}
+	if n, ok := Int(-7).Int(); !ok || n != -7 {
+		t.Errorf("Int() of -7 = %v, %v", n, ok)
+	}
+	if _, ok := BigInt(big.NewInt(1)).Object(); ok {
+		t.Error("bigint is an object")
+	}
+	if name := Decimal(big.NewRat(1, 2)).TypeName(); name != "decimal" {
+		t.Errorf("TypeName() of decimal = %s", name)
+	}
+}